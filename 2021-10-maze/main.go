@@ -139,6 +139,13 @@ func main() {
 	ps.Start(startSequence)
 
 	found := ps.WaitForFound()
+	if len(found) == 0 {
+		// Sequence.Search only tries slides after some commands, so this isn't proof that there is
+		// no solution
+		fmt.Println(colorize("red", "NO SOLUTION FOUND IN ", turns, " TURN(S)"))
+		fmt.Println("(Not proven impossible: see maze-ibm solve -rules october -certificate FILE in 2021-11-maze-2)")
+		os.Exit(2)
+	}
 	for _, s := range found {
 		sequence := s.(*Sequence)
 		sequence.PrintSummary()
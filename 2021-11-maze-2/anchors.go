@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Anchors are the cells of a maze which never move (like the fixed tiles of the Labyrinth board
+// game).  Either a row/column with an anchor in it can't be slid at all, or (if skip) the other
+// cells slide around the anchors as if they weren't there.
+type Anchors struct {
+	cells []bool
+	skip  bool
+}
+
+// ParseAnchors reads the anchored cells of a maze in the usual notation, e.g. "(1,1)"
+func ParseAnchors(maze *Maze, cells []string, skip bool) (*Anchors, error) {
+	anchors := &Anchors{make([]bool, maze.TotalCells()), skip}
+	for _, cell := range cells {
+		command, err := ParseCommand(maze, cell)
+		if err != nil || command.operation != MOVE {
+			return nil, fmt.Errorf("Invalid anchor: %s", cell)
+		}
+		anchors.cells[command.argument] = true
+	}
+	return anchors, nil
+}
+
+// Anchor is a copy of the maze with the given anchors (or none if nil)
+func (self *Maze) Anchor(anchors *Anchors) *Maze {
+	maze := self.Copy()
+	maze.anchors = anchors
+	return maze
+}
+
+// Anchored determines if the cell at the given location never moves
+func (self *Maze) Anchored(location uint8) bool {
+	return self.anchors != nil && self.anchors.cells[location]
+}
+
+// Blocked determines if the slide can't be made because an anchor is in the way
+func (self *Maze) Blocked(command Command) bool {
+	if self.anchors == nil || self.anchors.skip {
+		return false
+	}
+	for _, location := range self.line(command) {
+		if self.anchors.cells[location] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// apiRequest is a request to the API (the body of a POST to the web server or the argument of a
+// call from JavaScript).  Requests carry the scenario and every command played so far so that
+// nothing needs to be remembered between them.
+type apiRequest struct {
+	Scenario
+	Commands []string // Played from the start of the scenario
+	Command  string   // The command to apply (apply only)
+	Timeout  string   // How long to search for e.g. "30s" (solve only)
+}
+
+// apiCommand describes a command both as its parts and in the notation used everywhere else
+type apiCommand struct {
+	Command   string
+	Operation string
+	Argument  uint8
+	Rotation  uint8 `json:",omitempty"` // Quarter turns of the cell rotated (or of the spare tile)
+	Distance  uint8 `json:",omitempty"` // Cells slid along (long slides only)
+	Start     uint8 `json:",omitempty"` // Segment slid (from Start up to but not including End, if End isn't 0)
+	End       uint8 `json:",omitempty"`
+	TurnCost  uint8
+}
+
+// apiState is the state of the maze after the commands played
+type apiState struct {
+	Scenario
+	Commands       []string
+	Pattern        string // The maze as it is now
+	Location       uint8
+	TurnsUsed      int
+	TurnsRemaining uint8
+	Solved         bool
+	Reachable      []int // Where the player can walk to
+	Legal          []apiCommand
+}
+
+// apiSolution is the outcome of a solve
+type apiSolution struct {
+	Status   string // running, solved, unsolvable, timed out or cancelled
+	Solution []string
+	Turns    uint8
+	Searched []uint64
+	Elapsed  string
+}
+
+// apiSolveTime is how long a solve runs for when no timeout is given
+const apiSolveTime = 30 * time.Second
+
+var operationNames = map[uint8]string{
+	MOVE:        "move",
+	SLIDE_RIGHT: "slide right",
+	SLIDE_LEFT:  "slide left",
+	SLIDE_DOWN:  "slide down",
+	SLIDE_UP:    "slide up",
+	ROTATE:      "rotate",
+}
+
+// apiCalls are the operations offered by both the web server and the WebAssembly build
+var apiCalls = map[string]func(*apiRequest) (interface{}, error){
+	"maze":     apiMaze,
+	"apply":    apiApply,
+	"commands": apiCommands,
+	"solve":    apiSolve,
+}
+
+// callAPI runs the named call with a request given as JSON, returning the response as JSON (an
+// object with an Error if the call failed)
+func callAPI(name string, request []byte) []byte {
+	response, err := func() (interface{}, error) {
+		call, ok := apiCalls[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown call: %s", name)
+		}
+		decoded := &apiRequest{}
+		if err := json.Unmarshal(request, decoded); err != nil {
+			return nil, err
+		}
+		return call(decoded)
+	}()
+	if err != nil {
+		response = map[string]string{"Error": err.Error()}
+	}
+	dat, _ := json.Marshal(response)
+	return dat
+}
+
+// sequence replays the commands of the request from the start of its scenario
+func (self *apiRequest) sequence() (*Sequence, error) {
+	start, err := self.Scenario.Sequence()
+	if err != nil {
+		return nil, err
+	}
+	v := Verify(start.maze, start.turnsRemaining, start.rules, strings.Join(self.Commands, " "))
+	if v.Step > 0 {
+		return nil, fmt.Errorf("Step %d (%s): %s", v.Step, v.Token, v.Reason)
+	}
+	return v.Sequence, nil
+}
+
+// timeout is how long a solve may run for (never more than the given limit if there is one)
+func (self *apiRequest) timeout(limit time.Duration) (time.Duration, error) {
+	if self.Timeout == "" {
+		if limit > 0 {
+			return limit, nil
+		}
+		return apiSolveTime, nil
+	}
+	timeout, err := time.ParseDuration(self.Timeout)
+	if err != nil {
+		return 0, err
+	} else if limit > 0 && (timeout <= 0 || timeout > limit) {
+		return limit, nil
+	}
+	return timeout, nil
+}
+
+func (self *apiRequest) state(sequence *Sequence) *apiState {
+	state := &apiState{
+		Scenario:       self.Scenario,
+		Commands:       []string{},
+		Pattern:        sequence.maze.Pattern(),
+		Location:       sequence.location,
+		TurnsUsed:      int(self.Turns) - int(sequence.turnsRemaining),
+		TurnsRemaining: sequence.turnsRemaining,
+		Solved:         sequence.IsFound(),
+		Reachable:      []int{},
+		Legal:          legalCommands(sequence),
+	}
+	state.Commands = append(state.Commands, SolutionTokens(sequence.SolutionString())...)
+	for location := range sequence.maze.AccessibleLocations(sequence.location) {
+		state.Reachable = append(state.Reachable, int(location))
+	}
+	return state
+}
+
+// newAPICommand describes the command as run next from the sequence
+func newAPICommand(sequence *Sequence, cmd Command) apiCommand {
+	return apiCommand{cmd.String(sequence.maze.Columns()), operationNames[cmd.operation], cmd.argument, cmd.rotation, cmd.distance, cmd.start, cmd.end, sequence.TurnCost(cmd)}
+}
+
+func legalCommands(sequence *Sequence) []apiCommand {
+	commands := []apiCommand{}
+	for _, cmd := range sequence.LegalCommands() {
+		commands = append(commands, newAPICommand(sequence, cmd))
+	}
+	return commands
+}
+
+func newAPISolution(result *SolveResult) apiSolution {
+	solution := apiSolution{Turns: result.Turns, Searched: result.Searched, Elapsed: result.Elapsed.String()}
+	switch {
+	case result.Solution != nil:
+		solution.Status = "solved"
+		solution.Solution = []string{}
+		columns := result.Start.maze.Columns()
+		for _, cmd := range result.Commands() {
+			solution.Solution = append(solution.Solution, cmd.String(columns))
+		}
+	case result.Exhausted():
+		solution.Status = "unsolvable"
+	case result.TimedOut:
+		solution.Status = "timed out"
+	default:
+		solution.Status = "cancelled"
+	}
+	return solution
+}
+
+// apiMaze checks the scenario (and any commands played), returning the state arrived at
+func apiMaze(request *apiRequest) (interface{}, error) {
+	sequence, err := request.sequence()
+	if err != nil {
+		return nil, err
+	}
+	return request.state(sequence), nil
+}
+
+// apiApply plays the request's command after the others
+func apiApply(request *apiRequest) (interface{}, error) {
+	sequence, err := request.sequence()
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := sequence.CommandFromString(request.Command)
+	if err != nil {
+		return nil, err
+	}
+	if sequence.IsFound() {
+		return nil, fmt.Errorf("Already solved")
+	} else if sequence.TurnCost(cmd) > sequence.turnsRemaining {
+		return nil, fmt.Errorf("No turns remaining")
+	} else if !sequence.CanApply(cmd) {
+		return nil, fmt.Errorf("%s is not allowed", cmd.String(sequence.maze.Columns()))
+	}
+	next, err := sequence.Apply(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return request.state(next), nil
+}
+
+// apiCommands lists the commands which can be played next
+func apiCommands(request *apiRequest) (interface{}, error) {
+	sequence, err := request.sequence()
+	if err != nil {
+		return nil, err
+	}
+	return legalCommands(sequence), nil
+}
+
+// apiSolve searches for the shortest solution from the state of the request (waiting until done)
+func apiSolve(request *apiRequest) (interface{}, error) {
+	sequence, err := request.sequence()
+	if err != nil {
+		return nil, err
+	}
+	solver := NewSolver()
+	if solver.Timeout, err = request.timeout(0); err != nil {
+		return nil, err
+	}
+	return newAPISolution(solver.Solve(sequence)), nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const apiTestScenario = `"MazePattern": "43020c596163c1c9", "Columns": 4, "Rows": 4, "Turns": 3`
+
+// apiField is a field of a response object (or nil if the response isn't an object)
+func apiField(response interface{}, name string) interface{} {
+	if object, ok := response.(map[string]interface{}); ok {
+		return object[name]
+	}
+	return nil
+}
+
+// TestAPICalls runs every call offered to the web server and the WebAssembly build through
+// callAPI (which is all the JavaScript glue does)
+func TestAPICalls(t *testing.T) {
+	tests := map[string][]struct {
+		request string
+		check   func(response interface{}) bool
+	}{
+		"maze": {
+			{`{` + apiTestScenario + `}`, func(r interface{}) bool {
+				return apiField(r, "Pattern") == "43020c596163c1c9" && apiField(r, "TurnsRemaining") == 3.0
+			}},
+			{`{` + apiTestScenario + `, "Commands": ["(1,3)", "U2"]}`, func(r interface{}) bool {
+				return apiField(r, "TurnsUsed") == 2.0 && apiField(r, "Solved") == false
+			}},
+		},
+		"apply": {
+			{`{` + apiTestScenario + `, "Commands": ["(1,3)", "U2"], "Command": "(3,3)"}`, func(r interface{}) bool {
+				return apiField(r, "Solved") == true && apiField(r, "Location") == 15.0
+			}},
+		},
+		"commands": {
+			{`{` + apiTestScenario + `}`, func(r interface{}) bool {
+				commands, ok := r.([]interface{})
+				return ok && len(commands) > 0 && apiField(commands[0], "Command") != nil
+			}},
+		},
+		"solve": {
+			{`{` + apiTestScenario + `, "Timeout": "10s"}`, func(r interface{}) bool {
+				return apiField(r, "Status") == "solved" && apiField(r, "Turns") == 3.0
+			}},
+			{`{` + strings.Replace(apiTestScenario, `"Turns": 3`, `"Turns": 2`, 1) + `}`, func(r interface{}) bool {
+				return apiField(r, "Status") == "unsolvable"
+			}},
+		},
+	}
+
+	for name := range apiCalls {
+		if len(tests[name]) == 0 {
+			t.Errorf("%s: not tested", name)
+		}
+		for _, test := range tests[name] {
+			dat := callAPI(name, []byte(test.request))
+			var response interface{}
+			if err := json.Unmarshal(dat, &response); err != nil || apiField(response, "Error") != nil || !test.check(response) {
+				t.Errorf("%s %s: unexpected response %s", name, test.request, dat)
+			}
+		}
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		error   string
+	}{
+		{"unknown", `{}`, "Unknown call: unknown"},
+		{"maze", `{`, "unexpected end of JSON input"},
+		{"maze", `{"MazePattern": "43020c", "Columns": 4, "Rows": 4, "Turns": 3}`, "Maze pattern is not of size 4x4"},
+		{"maze", `{` + apiTestScenario + `, "Commands": ["(3,3)"]}`, "Step 1 ((3,3)): not allowed under november rules from (0,0)"},
+		{"apply", `{` + apiTestScenario + `, "Command": "X"}`, ""},
+		{"apply", `{` + apiTestScenario + `, "Command": "R4"}`, "Invalid shift: R4"},
+		{"solve", `{` + apiTestScenario + `, "Timeout": "soon"}`, ""},
+	}
+	for _, test := range tests {
+		response := map[string]interface{}{}
+		dat := callAPI(test.name, []byte(test.request))
+		if err := json.Unmarshal(dat, &response); err != nil {
+			t.Fatalf("%s %s: %s", test.name, test.request, err)
+		}
+		message, ok := response["Error"].(string)
+		if !ok || (test.error != "" && message != test.error) {
+			t.Errorf("%s %s: expected error %q but got %s", test.name, test.request, test.error, dat)
+		}
+	}
+}
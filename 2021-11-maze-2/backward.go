@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+)
+
+// BackwardResult is the outcome of searching backwards from the exit towards a start
+type BackwardResult struct {
+	Start  *Sequence
+	Bound  uint8
+	Turns  int   // Fewest turns from the start to the exit or -1 if more than the bound
+	Layers []int // States found at each number of turns from the exit
+	Mazes  int   // Arrangements of the maze within the bound's slides of the start
+}
+
+// backwardState is a state the game can be in (without the commands which led to it)
+type backwardState struct {
+	maze     *Maze
+	location uint8
+	moved    bool // The last command was a move (so a slide may follow for free with paired turns)
+}
+
+func (self backwardState) key() string {
+	return stateKey(self.maze, self.location, self.moved)
+}
+
+// sequence is a sequence in this state, so that the rules about what can be run next apply
+func (self backwardState) sequence(rules *Rules) *Sequence {
+	s := NewSequenceAt(self.maze, self.location, 255, rules)
+	if self.moved {
+		s = s.MoveSame()
+	}
+	return s
+}
+
+// BackwardSearch expands backwards from every state with the player at the exit (in any
+// arrangement of the maze the start could slide into) undoing one command at a time, fewest turns
+// first, until the start is found or every state within the bound has been found.  Either the
+// fewest turns needed are known exactly or it is proven that more than the bound are needed.
+func BackwardSearch(start *Sequence, bound uint8) *BackwardResult {
+	result := &BackwardResult{Start: start, Bound: bound, Turns: -1, Layers: []int{}}
+	maze := start.maze
+	rules := start.rules
+	exit := maze.TotalCells() - 1
+	changes := changeCommands(maze, rules)
+
+	// Every turn slides (or rotates) at most once, so a state needing more changes from the start
+	// than the turns left before it can't be on a route within the bound
+	slid, arrangements := slideDistances(maze, changes, bound)
+	result.Mazes = len(arrangements)
+
+	turns := map[string]int{}
+	type pending struct {
+		state backwardState
+		turns int
+	}
+	queue := []pending{}
+	reach := func(state backwardState, t int, free bool) {
+		if state.location == exit && t > 0 {
+			return // Nothing can be run once the exit has been reached
+		} else if d, ok := slid[state.maze.Pattern()]; !ok || int(d)+t > int(bound) {
+			return
+		} else if found, ok := turns[state.key()]; ok && found <= t {
+			return
+		}
+		turns[state.key()] = t
+		if free {
+			queue = append([]pending{{state, t}}, queue...)
+		} else {
+			queue = append(queue, pending{state, t})
+		}
+	}
+	for _, arrangement := range arrangements {
+		reach(backwardState{arrangement, exit, false}, 0, false)
+		reach(backwardState{arrangement, exit, true}, 0, false)
+	}
+
+	startKey := start.stateKey()
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		key := p.state.key()
+		if turns[key] < p.turns {
+			continue // Since found again in fewer turns
+		}
+		for len(result.Layers) <= p.turns {
+			result.Layers = append(result.Layers, 0)
+		}
+		result.Layers[p.turns]++
+		if key == startKey {
+			result.Turns = p.turns
+			break
+		}
+
+		state := p.state
+		if state.moved {
+			// Moved here from anywhere the player could walk from (in the same arrangement)
+			for _, location := range state.maze.component(state.location) {
+				for _, moved := range []bool{false, true} {
+					reach(backwardState{state.maze, location, moved}, p.turns+1, false)
+				}
+			}
+			continue
+		}
+		// Otherwise slid (or rotated) here, so undo it (carrying the player back if they were carried)
+		for _, change := range changes {
+			undone, err := state.maze.Undo(change)
+			if err != nil {
+				continue
+			}
+			previous := backwardState{maze: undone, location: state.location}
+			if rules.CarryPlayer {
+				previous.location = previous.maze.SlideLocation(change.Inverse(), state.location)
+			}
+			for _, moved := range []bool{false, true} {
+				previous.moved = moved
+				s := previous.sequence(rules)
+				if !s.CanApply(change) {
+					continue
+				}
+				cost := s.TurnCost(change)
+				reach(previous, p.turns+int(cost), cost == 0)
+			}
+		}
+	}
+	return result
+}
+
+// changeCommands are every slide the rules allow (turning the spare tile every way, if there is one,
+// sliding every distance and every segment if they are allowed) and every rotation of a cell if
+// they are allowed
+func changeCommands(maze *Maze, rules *Rules) []Command {
+	rotations := []uint8{0}
+	if rules.SpareTile {
+		rotations = []uint8{0, 1, 2, 3}
+	}
+	operations := []uint8{SLIDE_RIGHT, SLIDE_DOWN}
+	if rules.ReverseSlides {
+		operations = append(operations, SLIDE_LEFT, SLIDE_UP)
+	}
+	changes := []Command{}
+	for _, operation := range operations {
+		limit, length := maze.Rows(), maze.Columns()
+		if operation == SLIDE_DOWN || operation == SLIDE_UP {
+			limit, length = maze.Columns(), maze.Rows()
+		}
+		segments := [][2]uint8{{0, 0}}
+		for start := uint8(0); rules.SegmentSlides && start+1 < length; start++ {
+			for end := start + 2; end <= length; end++ {
+				if end-start < length {
+					segments = append(segments, [2]uint8{start, end})
+				}
+			}
+		}
+		for argument := uint8(0); argument < limit; argument++ {
+			for _, segment := range segments {
+				// Never further than the length of the segment (or of the whole row/column)
+				slid := length
+				if segment[1] > 0 {
+					slid = segment[1] - segment[0]
+				}
+				distances := []uint8{0}
+				for distance := uint8(2); rules.LongSlides && !rules.SpareTile && distance < slid; distance++ {
+					distances = append(distances, distance)
+				}
+				for _, rotation := range rotations {
+					for _, distance := range distances {
+						changes = append(changes, Command{operation, argument, rotation, distance, segment[0], segment[1]})
+					}
+				}
+			}
+		}
+	}
+	if rules.RotateTiles {
+		for location := uint8(0); location < maze.TotalCells(); location++ {
+			for rotation := uint8(1); rotation < 4; rotation++ {
+				changes = append(changes, Command{ROTATE, location, rotation, 0, 0, 0})
+			}
+		}
+	}
+	return changes
+}
+
+// slideDistances are the fewest slides (or rotations) needed to arrive at each arrangement of the
+// maze (by pattern) within the limit, along with the arrangements themselves.  Those the player's
+// location might forbid are allowed, so these are never more than actually needed.
+func slideDistances(maze *Maze, slides []Command, limit uint8) (map[string]uint8, []*Maze) {
+	distances := map[string]uint8{maze.Pattern(): 0}
+	arrangements := []*Maze{maze}
+	layer := []*Maze{maze}
+	for d := uint8(1); d <= limit && len(layer) > 0; d++ {
+		next := []*Maze{}
+		for _, m := range layer {
+			for _, slide := range slides {
+				slid, err := m.Change(slide)
+				if err != nil {
+					continue
+				}
+				if _, ok := distances[slid.Pattern()]; !ok {
+					distances[slid.Pattern()] = d
+					next = append(next, slid)
+				}
+			}
+		}
+		arrangements = append(arrangements, next...)
+		layer = next
+	}
+	return distances, arrangements
+}
+
+// Exceeded determines if more turns than the bound are needed
+func (self *BackwardResult) Exceeded() bool {
+	return self.Turns < 0
+}
+
+// LowerBound is the fewest turns any solution could use (exact unless exceeded)
+func (self *BackwardResult) LowerBound() int {
+	if self.Exceeded() {
+		return int(self.Bound) + 1
+	}
+	return self.Turns
+}
+
+func (self *BackwardResult) String() string {
+	states := 0
+	for _, count := range self.Layers {
+		states += count
+	}
+	if self.Exceeded() {
+		return fmt.Sprintf("More than %d turn(s) needed (%d states within %d turn(s) of the exit, %d arrangements)",
+			self.Bound, states, self.Bound, self.Mazes)
+	}
+	return fmt.Sprintf("Exactly %d turn(s) needed (%d states searched backwards, %d arrangements)",
+		self.Turns, states, self.Mazes)
+}
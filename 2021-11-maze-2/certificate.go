@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Certificate records that no solution exists within a number of turns, along with everything
+// needed to judge and reproduce the claim: how much was searched and what was left unsearched
+type Certificate struct {
+	Scenario       Scenario
+	Rules          *Rules
+	Claim          string
+	Turns          uint8                // No solution uses this many turns or fewer
+	DepthLimit     int                  // Most commands searched (0 if the backward search alone proved it)
+	NodesPerDepth  []uint64             // Sequences searched at each depth with the full budget
+	Backward       *BackwardCertificate `json:",omitempty"`
+	Pruning        []Pruning
+	PruningSafe    bool   // Every pruning's reason is argued for the rules searched
+	Reproduce      string // Command line which repeats the search
+	Elapsed        string
+	ElapsedSeconds float64
+}
+
+// BackwardCertificate is the part played by a backward search (proving budgets were too few)
+type BackwardCertificate struct {
+	Bound      uint8
+	LowerBound int   // Fewest turns any solution could use
+	Layers     []int // States found at each number of turns from the exit
+	Mazes      int
+}
+
+// Pruning is a way the search skips commands and why doing so can't lose a solution.  The reason
+// is only argued for some rules, so a pruning is only marked safe under those.
+type Pruning struct {
+	Name   string
+	Rule   string
+	Safe   bool // The reason is argued for the rules searched
+	Reason string
+
+	argued func(rules *Rules) bool // Rules the reason is argued for
+}
+
+// searchPruning are the commands skipped by Sequence.eachNext along with the turn budget (by
+// depthLimit)
+var searchPruning = []Pruning{
+	{
+		Name:   "consecutive-moves",
+		Rule:   "A move is never followed by another move",
+		Reason: "Any cell reachable in two moves is reachable in one, which leaves the same state in fewer turns",
+		argued: func(rules *Rules) bool { return true },
+	},
+	{
+		Name:   "move-in-place",
+		Rule:   "The player never moves to the cell they are in",
+		Reason: "Moving in place changes nothing except making a following slide free (with paired turns), which uses the same turn as paying for the slide",
+		argued: func(rules *Rules) bool { return !rules.RotateTiles || !rules.PairedTurns },
+	},
+	{
+		Name:   "slide-order",
+		Rule:   "Consecutive slides in the same direction are made in order of row/column",
+		Reason: "Slides of different rows (or columns) in the same direction move different cells, so either order leaves the same state in the same turns",
+		argued: func(rules *Rules) bool { return !rules.SpareTile },
+	},
+	{
+		Name:   "depth-limit",
+		Rule:   "No more commands are searched than can be run in the turns (two per turn plus one with paired turns)",
+		Reason: "Every command uses a turn except a slide straight after a move",
+		argued: func(rules *Rules) bool { return !rules.RotateTiles || !rules.PairedTurns },
+	},
+}
+
+// sparePruning replaces slide-order when there is a spare tile (which passes from one slide to the
+// next, so slides are never reordered)
+var sparePruning = Pruning{
+	Name:   "spare-rotation",
+	Rule:   "The spare tile is only turned in ways which make it look different",
+	Reason: "Turning a symmetric tile (e.g. a straight corridor by half a turn) pushes in the same tile",
+	argued: func(rules *Rules) bool { return rules.SpareTile },
+}
+
+// rotationPruning applies when cells may be rotated
+var rotationPruning = Pruning{
+	Name:   "rotation-order",
+	Rule:   "Consecutive rotations are made in order of cell, never rotating the same cell twice in a row",
+	Reason: "Rotations of different cells change different walls so can be made in either order, and two rotations of a cell are the same as one",
+	argued: func(rules *Rules) bool { return rules.RotateTiles },
+}
+
+// longSlidePruning applies when rows/columns may be slid along by any number of cells.  Nothing is
+// argued for segments (which may be slid twice in a row) or for pushing in a spare tile (which
+// makes sliding left differ from sliding right).
+var longSlidePruning = Pruning{
+	Name:   "long-slides",
+	Rule:   "Rows/columns are only slid right/down, and never twice in a row",
+	Reason: "Sliding left/up by some cells is the same as sliding right/down by the rest, and two slides of a row/column are the same as one longer slide",
+	argued: func(rules *Rules) bool { return rules.LongSlides && !rules.SegmentSlides && !rules.SpareTile },
+}
+
+// backwardPruning is what a backward search leaves unsearched
+var backwardPruning = Pruning{
+	Name:   "slide-distance",
+	Rule:   "Searching backwards, arrangements needing more slides (or rotations) from the start than the turns left are skipped",
+	Reason: "Every turn slides (or rotates) at most once, and these are counted ignoring the player so are never more than needed",
+	argued: func(rules *Rules) bool { return true },
+}
+
+// NewCertificate certifies that the solve found no solution within the start's turns remaining (or
+// returns why it can't, e.g. because the search timed out).  The backward search run first, if
+// any, is included since it may have proven some or all of the budgets too few.
+func NewCertificate(scenario *Scenario, result *SolveResult, backward *BackwardResult) (*Certificate, error) {
+	if result.Solution != nil {
+		return nil, fmt.Errorf("A solution was found in %d turn(s)", result.Turns)
+	} else if !result.Exhausted() {
+		return nil, fmt.Errorf("The search was not exhaustive (only %d turn(s) fully searched)", result.Turns)
+	}
+
+	turns := result.Start.turnsRemaining
+	certificate := &Certificate{
+		Scenario:       *scenario,
+		Rules:          result.Start.rules,
+		Claim:          fmt.Sprintf("No solution within %d turn(s)", turns),
+		Turns:          turns,
+		NodesPerDepth:  result.Searched,
+		Pruning:        []Pruning{},
+		Reproduce:      fmt.Sprintf("maze-ibm solve -rules %s", result.Start.rules.Name),
+		Elapsed:        result.Elapsed.String(),
+		ElapsedSeconds: result.Elapsed.Seconds(),
+	}
+	if certificate.NodesPerDepth == nil {
+		certificate.NodesPerDepth = []uint64{}
+	} else {
+		certificate.DepthLimit = depthLimit(turns, result.Start.rules)
+	}
+	for _, pruning := range searchPruning {
+		if pruning.Name == "slide-order" && result.Start.rules.SpareTile {
+			pruning = sparePruning
+		}
+		certificate.Pruning = append(certificate.Pruning, pruning)
+	}
+	if result.Start.rules.RotateTiles {
+		certificate.Pruning = append(certificate.Pruning, rotationPruning)
+	}
+	if result.Start.rules.LongSlides {
+		certificate.Pruning = append(certificate.Pruning, longSlidePruning)
+	}
+	if backward != nil {
+		certificate.Backward = &BackwardCertificate{backward.Bound, backward.LowerBound(), backward.Layers, backward.Mazes}
+		certificate.Pruning = append(certificate.Pruning, backwardPruning)
+		certificate.Reproduce += fmt.Sprintf(" -backward %d", backward.Bound)
+	}
+	if len(scenario.Anchors) > 0 {
+		certificate.Reproduce += fmt.Sprintf(" -anchors \"%s\"", strings.Join(scenario.Anchors, " "))
+		if scenario.SkipAnchors {
+			certificate.Reproduce += " -skip-anchors"
+		}
+	}
+	certificate.Reproduce += fmt.Sprintf(" %s %dx%d %d", scenario.MazePattern, scenario.Rows, scenario.Columns, turns)
+
+	certificate.PruningSafe = true
+	for i := range certificate.Pruning {
+		pruning := &certificate.Pruning[i]
+		pruning.Safe = pruning.argued(certificate.Rules)
+		certificate.PruningSafe = certificate.PruningSafe && pruning.Safe
+	}
+	if !certificate.PruningSafe {
+		certificate.Claim += " (but some pruning isn't argued for these rules)"
+	}
+	return certificate, nil
+}
+
+func (self *Certificate) String() string {
+	searched := []string{}
+	for _, nodes := range self.NodesPerDepth {
+		searched = append(searched, fmt.Sprint(nodes))
+	}
+	s := self.Claim
+	if len(searched) > 0 {
+		s += fmt.Sprintf(", searched %s sequence(s) by depth", strings.Join(searched, "/"))
+	}
+	if self.Backward != nil {
+		s += fmt.Sprintf(", at least %d turn(s) needed searching backwards", self.Backward.LowerBound)
+	}
+	return s
+}
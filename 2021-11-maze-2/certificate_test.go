@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+)
+
+// unprunedTurns is the fewest turns needed from the start (or -1 if more than its turns remaining)
+// found by trying every legal command in every state, fewest turns first
+func unprunedTurns(start *Sequence) int {
+	remaining := map[string]uint8{start.stateKey(): start.turnsRemaining}
+	queue := []*Sequence{start}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if s.IsFound() {
+			return int(start.turnsRemaining - s.turnsRemaining)
+		} else if remaining[s.stateKey()] > s.turnsRemaining {
+			continue // Since found again in fewer turns
+		}
+		for _, command := range s.LegalCommands() {
+			next, err := s.Apply(command)
+			if err != nil {
+				continue
+			}
+			if found, ok := remaining[next.stateKey()]; ok && found >= next.turnsRemaining {
+				continue
+			}
+			remaining[next.stateKey()] = next.turnsRemaining
+			if next.turnsRemaining == s.turnsRemaining {
+				queue = append([]*Sequence{next}, queue...)
+			} else {
+				queue = append(queue, next)
+			}
+		}
+	}
+	return -1
+}
+
+func TestPrunedSearchAgreesWithUnpruned(t *testing.T) {
+	// Pruning only matters in mazes needing several turns (and those needing more than the bound),
+	// so enough mazes are tried under each set of rules to find some of each
+	tests := []struct {
+		rules         *Rules
+		rows, columns uint8
+		bound         uint8
+		turns         int // Fewest turns needed by the hard mazes
+		hard          int // Hard mazes to check
+		unsolved      int // Mazes needing more than the bound to check
+	}{
+		{OctoberRules, 4, 4, 4, 3, 6, 2},
+		{NovemberRules, 4, 4, 4, 3, 6, 2},
+		{LabyrinthRules, 3, 4, 3, 3, 4, 2},
+		{RotatingRules, 3, 4, 3, 3, 4, 2},
+		{ExpressRules, 4, 4, 2, 2, 6, 0}, // Every maze tried can be solved in 2 turns
+		{SegmentRules, 3, 3, 3, 3, 4, 2},
+		{TorusRules, 4, 4, 4, 3, 6, 2},
+	}
+	for _, rules := range allRules {
+		tested := false
+		for _, test := range tests {
+			tested = tested || test.rules == rules
+		}
+		if !tested {
+			t.Errorf("%s: not tested", rules)
+		}
+	}
+
+	for _, test := range tests {
+		rules := test.rules
+		hard, unsolved := 0, 0
+		for i, maze := range testMazes(rules, 300, test.rows, test.columns) {
+			if hard == test.hard && unsolved == test.unsolved {
+				break
+			}
+			start := NewSequence(maze, test.bound, rules)
+			got := solvedTurns(start)
+			if got == -1 && unsolved < test.unsolved {
+				unsolved++
+			} else if got >= test.turns && hard < test.hard {
+				hard++
+			} else {
+				continue
+			}
+			if want := unprunedTurns(start); got != want {
+				t.Errorf("%s maze %d (%s): solver needs %d turn(s) but %d are enough", rules, i, maze.Pattern(), got, want)
+			}
+		}
+		if hard < test.hard || unsolved < test.unsolved {
+			t.Errorf("%s: only %d maze(s) needing %d to %d turns and %d needing more found", rules, hard, test.turns, test.bound, unsolved)
+		}
+	}
+}
+
+func TestPruningSafety(t *testing.T) {
+	maze := testMazes(NovemberRules, 1, 3, 3)[0]
+	tests := []struct {
+		rules *Rules
+		safe  bool
+	}{
+		{OctoberRules, true},
+		{NovemberRules, true},
+		{LabyrinthRules, true},
+		{RotatingRules, true},
+		{ExpressRules, true},
+		{SegmentRules, true},
+		{TorusRules, true},
+		{&Rules{Name: "unargued", ReverseSlides: true, LongSlides: true, SegmentSlides: true}, false},
+		{&Rules{Name: "unargued", ReverseSlides: true, LongSlides: true, SpareTile: true}, false},
+		{&Rules{Name: "unargued", PairedTurns: true, RotateTiles: true}, false},
+	}
+	for _, test := range tests {
+		m := maze
+		if test.rules.SpareTile {
+			m = maze.Copy()
+			m.spare, m.hasSpare = 5, true
+		}
+		scenario := &Scenario{Columns: m.Columns(), Rows: m.Rows(), MazePattern: m.Pattern(), Rules: test.rules.Name}
+		result := NewSolver().Solve(NewSequence(m, 0, test.rules))
+		certificate, err := NewCertificate(scenario, result, nil)
+		if err != nil {
+			t.Fatalf("%s: %s", test.rules, err)
+		}
+		if certificate.PruningSafe != test.safe {
+			t.Errorf("%s: pruning safe is %v (%s)", test.rules, certificate.PruningSafe, certificate.Claim)
+		}
+		for _, pruning := range certificate.Pruning {
+			if pruning.Safe != pruning.argued(test.rules) {
+				t.Errorf("%s: %s marked safe %v", test.rules, pruning.Name, pruning.Safe)
+			}
+		}
+	}
+}
@@ -2,14 +2,14 @@ package main
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/gookit/color"
 )
 
-func colorize(colorName string, a ...interface{}) string {
+// colorizeIf only adds color when enabled (e.g. not when rendering to a file)
+func colorizeIf(enabled bool, colorName string, a ...interface{}) string {
 	s := fmt.Sprint(a...)
-	if fileInfo, _ := os.Stdout.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
+	if enabled {
 		return color.Sprint("<", colorName, ">", s, "</>")
 	}
 	return s
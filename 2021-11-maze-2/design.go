@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DesignOptions control the search for mazes which need as many turns as possible
+type DesignOptions struct {
+	Rows         uint8
+	Columns      uint8
+	Rules        *Rules
+	MaxTurns     uint8         // Candidates are only solved up to this many turns
+	SolveTimeout time.Duration // Candidates which take longer than this to solve are rejected
+	Workers      int           // How many candidates are evaluated at once
+	Temperature  float64       // Initial temperature (0 only accepts candidates at least as good: hill climbing)
+	Cooling      float64       // The temperature is multiplied by this after each round
+	Keep         int           // How many of the best mazes to remember
+	Seed         int64
+}
+
+// DesignedMaze is a maze found by the designer along with the fewest turns it is proven to need
+type DesignedMaze struct {
+	Pattern  string
+	Turns    uint8
+	Solution string
+}
+
+// Designer runs a local search (simulated annealing) over maze patterns: each round several
+// candidates are made by toggling walls of the current maze and the one needing the most turns
+// replaces it (if it is better, or by chance while the temperature is high).  A designer can be
+// saved as a checkpoint and resumed later.
+type Designer struct {
+	Options     DesignOptions
+	Round       int
+	Temperature float64
+	Evaluated   int // Candidates solved so far
+	Current     *DesignedMaze
+	Best        []DesignedMaze
+}
+
+func NewDesigner(options DesignOptions) *Designer {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	return &Designer{Options: options, Temperature: options.Temperature}
+}
+
+// Start sets the maze to start searching from (a random one is used if there isn't one)
+func (self *Designer) Start(pattern string) error {
+	maze, err := ParseMaze(pattern, self.Options.Columns)
+	if err != nil {
+		return err
+	}
+	designed, ok := self.evaluate(maze, time.Time{})
+	if !ok {
+		return fmt.Errorf("Maze can't be solved within %d turn(s)", self.Options.MaxTurns)
+	}
+	self.Current = &designed
+	self.remember(designed)
+	return nil
+}
+
+// Run searches until the deadline, calling back after every round (and stopping early if the
+// current maze can't be read)
+func (self *Designer) Run(deadline time.Time, onRound func(improved bool)) error {
+	for time.Now().Before(deadline) {
+		improved, err := self.step(deadline)
+		if err != nil {
+			return err
+		}
+		onRound(improved)
+	}
+	return nil
+}
+
+// step runs a round of the search, returning whether a new best maze was found
+func (self *Designer) step(deadline time.Time) (bool, error) {
+	options := self.Options
+	r := rand.New(rand.NewSource(options.Seed + int64(self.Round)))
+	defer func() {
+		self.Round++
+		self.Temperature *= options.Cooling
+	}()
+
+	if self.Current == nil {
+		// Keep trying random mazes until one is solvable
+		maze := randomMaze(r, GeneratorOptions{Rows: options.Rows, Columns: options.Columns, Rules: options.Rules, WallDensity: 0.5})
+		self.Evaluated++
+		if designed, ok := self.evaluate(maze, deadline); ok {
+			self.Current = &designed
+			return self.remember(designed), nil
+		}
+		return false, nil
+	}
+
+	current, err := ParseMaze(self.Current.Pattern, options.Columns)
+	if err != nil {
+		return false, err
+	}
+	candidates := make([]*Maze, options.Workers)
+	for i := range candidates {
+		candidates[i] = toggleWalls(current, r, 1+r.Intn(2))
+	}
+
+	results := make([]*DesignedMaze, len(candidates))
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func(i int, candidate *Maze) {
+			defer wg.Done()
+			if designed, ok := self.evaluate(candidate, deadline); ok {
+				results[i] = &designed
+			}
+		}(i, candidate)
+	}
+	wg.Wait()
+	self.Evaluated += len(candidates)
+
+	var next *DesignedMaze
+	improved := false
+	for _, result := range results {
+		if result != nil {
+			improved = self.remember(*result) || improved
+			if next == nil || result.Turns > next.Turns {
+				next = result
+			}
+		}
+	}
+	if next != nil {
+		change := float64(next.Turns) - float64(self.Current.Turns)
+		if change >= 0 || (self.Temperature > 0 && r.Float64() < math.Exp(change/self.Temperature)) {
+			self.Current = next
+		}
+	}
+	return improved, nil
+}
+
+// evaluate solves the maze, returning how many turns it needs (if it can be solved in time)
+func (self *Designer) evaluate(maze *Maze, deadline time.Time) (DesignedMaze, bool) {
+	options := self.Options
+	solver := NewSolver()
+	solver.PoolSize = 8 * runtime.NumCPU() / options.Workers
+	if solver.PoolSize < 1 {
+		solver.PoolSize = 1
+	}
+	solver.Timeout = options.SolveTimeout
+	if remaining := time.Until(deadline); !deadline.IsZero() && (solver.Timeout == 0 || remaining < solver.Timeout) {
+		solver.Timeout = remaining
+		if remaining <= 0 {
+			return DesignedMaze{}, false
+		}
+	}
+
+	result := solver.Solve(NewSequence(maze, options.MaxTurns, options.Rules))
+	if result.Solution == nil {
+		return DesignedMaze{}, false
+	}
+	return DesignedMaze{maze.Pattern(), result.Turns, strings.TrimSpace(result.Solution.SolutionString())}, true
+}
+
+// remember adds the maze to the best found (if it is good enough), returning whether it is the
+// new best
+func (self *Designer) remember(designed DesignedMaze) bool {
+	for _, best := range self.Best {
+		if best.Pattern == designed.Pattern {
+			return false
+		}
+	}
+	improved := len(self.Best) == 0 || designed.Turns > self.Best[0].Turns
+	self.Best = append(self.Best, designed)
+	sort.SliceStable(self.Best, func(i, j int) bool {
+		return self.Best[i].Turns > self.Best[j].Turns
+	})
+	if len(self.Best) > self.Options.Keep {
+		self.Best = self.Best[:self.Options.Keep]
+	}
+	return improved
+}
+
+// toggleWalls opens (or closes) the given number of walls between neighbouring cells at random,
+// always changing both sides of a wall together
+func toggleWalls(maze *Maze, r *rand.Rand, walls int) *Maze {
+	maze = maze.Copy()
+	columns := int(maze.Columns())
+	rows := int(maze.Rows())
+	horizontal := rows * (columns - 1) // Walls between a cell and the one to its east
+	total := horizontal + (rows-1)*columns
+	if total == 0 {
+		return maze
+	}
+
+	for i := 0; i < walls; i++ {
+		wall := r.Intn(total)
+		var location, neighbour int
+		var side, neighbourSide byte
+		if wall < horizontal {
+			location = wall/(columns-1)*columns + wall%(columns-1)
+			neighbour, side, neighbourSide = location+1, 4, 1
+		} else {
+			location = wall - horizontal
+			neighbour, side, neighbourSide = location+columns, 2, 8
+		}
+		if maze.cells[location]&side > 0 && maze.cells[neighbour]&neighbourSide > 0 {
+			maze.cells[location] &^= side
+			maze.cells[neighbour] &^= neighbourSide
+		} else {
+			maze.cells[location] |= side
+			maze.cells[neighbour] |= neighbourSide
+		}
+	}
+	return maze
+}
+
+// Save writes the designer as a checkpoint which can be resumed
+func (self *Designer) Save(path string) error {
+	dat, err := json.MarshalIndent(self, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(dat, '\n'), 0644)
+}
+
+// LoadDesigner resumes a designer from a checkpoint
+func LoadDesigner(path string) (*Designer, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	designer := &Designer{}
+	if err = json.Unmarshal(dat, designer); err != nil {
+		return nil, err
+	}
+	if designer.Options.Rules == nil {
+		return nil, fmt.Errorf("%s: no rules", path)
+	}
+	// Use the rules themselves rather than the copy read from the file
+	if designer.Options.Rules, err = RulesByName(designer.Options.Rules.Name); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return designer, nil
+}
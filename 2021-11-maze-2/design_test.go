@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// wallsDiffering counts the walls between neighbouring cells which are open in one maze but not
+// the other (failing if either side of a wall disagrees with the other)
+func wallsDiffering(t *testing.T, before *Maze, after *Maze) int {
+	columns := int(after.Columns())
+	differing := 0
+	for location, cell := range after.cells {
+		if (location+1)%columns != 0 {
+			if (cell&4 != 0) != (after.cells[location+1]&1 != 0) {
+				t.Errorf("%s: sides of the wall east of %d disagree", after.Pattern(), location)
+			}
+			if (cell^before.cells[location])&4 != 0 {
+				differing++
+			}
+		}
+		if location+columns < len(after.cells) {
+			if (cell&2 != 0) != (after.cells[location+columns]&8 != 0) {
+				t.Errorf("%s: sides of the wall south of %d disagree", after.Pattern(), location)
+			}
+			if (cell^before.cells[location])&2 != 0 {
+				differing++
+			}
+		}
+	}
+	return differing
+}
+
+func TestToggleWalls(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tests := []struct {
+		pattern string
+		columns uint8
+		walls   int
+	}{
+		{"43020c596163c1c9", 4, 1},
+		{"43020c596163c1c9", 4, 2},
+		{"000000000000", 3, 3},
+		{"653c59", 3, 1},
+		{"0000", 1, 2}, // A single column only has walls to the south
+	}
+	for _, test := range tests {
+		maze := mustParseMaze(test.pattern, test.columns)
+		for i := 0; i < 20; i++ {
+			toggled := toggleWalls(maze, r, test.walls)
+			if maze.Pattern() != test.pattern {
+				t.Fatalf("%s: changed to %s", test.pattern, maze.Pattern())
+			}
+			// A wall may be toggled back again
+			if differing := wallsDiffering(t, maze, toggled); differing > test.walls || differing%2 != test.walls%2 {
+				t.Errorf("%s: %d wall(s) toggled as %s", test.pattern, test.walls, toggled.Pattern())
+			}
+		}
+	}
+
+	if toggled := toggleWalls(mustParseMaze("5", 1), r, 1); toggled.Pattern() != "5" {
+		t.Errorf("Maze without walls between cells toggled to %s", toggled.Pattern())
+	}
+}
+
+func TestDesignerRemember(t *testing.T) {
+	designer := NewDesigner(DesignOptions{Keep: 3})
+	tests := []struct {
+		pattern  string
+		turns    uint8
+		improved bool
+		best     string // Turns of the best mazes remembered
+	}{
+		{"a", 2, true, "[a2]"},
+		{"b", 1, false, "[a2 b1]"},
+		{"c", 3, true, "[c3 a2 b1]"},
+		{"c", 3, false, "[c3 a2 b1]"},
+		{"d", 2, false, "[c3 a2 d2]"},
+		{"e", 1, false, "[c3 a2 d2]"},
+		{"f", 4, true, "[f4 c3 a2]"},
+	}
+	for _, test := range tests {
+		improved := designer.remember(DesignedMaze{Pattern: test.pattern, Turns: test.turns})
+		best := []string{}
+		for _, designed := range designer.Best {
+			best = append(best, fmt.Sprintf("%s%d", designed.Pattern, designed.Turns))
+		}
+		if improved != test.improved || fmt.Sprint(best) != test.best {
+			t.Errorf("%s%d: improved %v with best %v, expected %v %s", test.pattern, test.turns, improved, best, test.improved, test.best)
+		}
+	}
+}
+
+func TestDesigner(t *testing.T) {
+	options := DesignOptions{Rows: 3, Columns: 3, Rules: NovemberRules, MaxTurns: 4, Workers: 2, Temperature: 1, Cooling: 0.9, Keep: 4, Seed: 7}
+	deadline := time.Now().Add(time.Hour)
+	run := func(designer *Designer, rounds int) {
+		for i := 0; i < rounds; i++ {
+			if _, err := designer.step(deadline); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The same seed designs the same mazes, even when resumed from a checkpoint
+	designer := NewDesigner(options)
+	run(designer, 10)
+	path := filepath.Join(t.TempDir(), "design.json")
+	if err := designer.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	run(designer, 10)
+	resumed, err := LoadDesigner(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Options.Rules != NovemberRules {
+		t.Errorf("Resumed with rules %+v", resumed.Options.Rules)
+	}
+	run(resumed, 10)
+	// (though the solutions found may differ)
+	designs := func(designer *Designer) string {
+		designs := []string{}
+		for _, designed := range designer.Best {
+			designs = append(designs, fmt.Sprintf("%s:%d", designed.Pattern, designed.Turns))
+		}
+		return fmt.Sprintf("%v %s round %d after %d", designs, designer.Current.Pattern, designer.Round, designer.Evaluated)
+	}
+	if designs(resumed) != designs(designer) {
+		t.Errorf("Resumed as %s, expected %s", designs(resumed), designs(designer))
+	}
+
+	if len(designer.Best) == 0 || len(designer.Best) > options.Keep {
+		t.Fatalf("Designed %+v", designer)
+	}
+	for _, designed := range designer.Best {
+		maze := mustParseMaze(designed.Pattern, options.Columns)
+		verification := Verify(maze, designed.Turns, NovemberRules, designed.Solution)
+		if !verification.Valid() {
+			t.Errorf("%+v: %s", designed, verification)
+		}
+		if designed.Turns > 0 {
+			if result := NewSolver().Solve(NewSequence(maze, designed.Turns-1, NovemberRules)); result.Solution != nil {
+				t.Errorf("%+v: solved in fewer turns", designed)
+			}
+		}
+	}
+
+	if err := NewDesigner(options).Start("000000000"); err == nil {
+		t.Error("Started from a maze which can't be solved")
+	}
+
+	// A checkpoint with a corrupt maze stops the search rather than crashing it
+	broken := NewDesigner(options)
+	broken.Current = &DesignedMaze{Pattern: "00000000x"}
+	if err := broken.Run(deadline, func(bool) {}); err == nil {
+		t.Error("Ran from a corrupt maze")
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// nowhere is never a cell (since patterns are shorter than 256 characters) so can be given as the
+// player's location to draw a maze without the player
+const nowhere uint8 = 255
+
+// DistanceMap is the fewest turns needed to reach the exit from each cell the player could start in
+type DistanceMap struct {
+	Rows    uint8
+	Columns uint8
+	Rules   *Rules
+	Limit   uint8 // Most turns searched
+	Turns   []int // For each cell (row by row) or -1 if the exit can't be reached within the limit
+	States  int   // States visited to find them
+}
+
+// distanceEdge leads to a state from the state it came from by a command costing the given turns
+type distanceEdge struct {
+	from int
+	cost uint8
+}
+
+// NewDistanceMap finds the fewest turns from every start cell at once.  Every state within the turn
+// limit of any start is visited once (fewest turns first) recording how it was arrived at, then
+// the turns to the exit are counted backwards from every state with the player at the exit.
+func NewDistanceMap(maze *Maze, rules *Rules, limit uint8) *DistanceMap {
+	distances := &DistanceMap{Rows: maze.Rows(), Columns: maze.Columns(), Rules: rules, Limit: limit}
+
+	index := map[string]int{}
+	best := []uint8{}              // Most turns remaining each state has been queued with
+	arrivals := [][]distanceEdge{} // How each state can be arrived at
+	exits := []int{}
+	state := func(s *Sequence) int {
+		i, ok := index[s.stateKey()]
+		if !ok {
+			i = len(best)
+			index[s.stateKey()] = i
+			best = append(best, s.turnsRemaining)
+			arrivals = append(arrivals, nil)
+			if s.IsFound() {
+				exits = append(exits, i)
+			}
+		}
+		return i
+	}
+
+	starts := make([]int, maze.TotalCells())
+	queue := []*Sequence{}
+	for location := uint8(0); location < maze.TotalCells(); location++ {
+		start := NewSequenceAt(maze, location, limit, rules)
+		starts[location] = state(start)
+		queue = append(queue, start)
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		from := state(s)
+		if best[from] > s.turnsRemaining {
+			continue // Since queued again with more turns remaining
+		}
+		for _, cmd := range s.LegalCommands() {
+			next, err := s.Apply(cmd)
+			if err != nil {
+				continue
+			}
+			to, seen := index[next.stateKey()]
+			if !seen {
+				to = state(next)
+			}
+			arrivals[to] = append(arrivals[to], distanceEdge{from, s.TurnCost(cmd)})
+			if seen && best[to] >= next.turnsRemaining {
+				continue
+			}
+			best[to] = next.turnsRemaining
+			if next.turnsRemaining == s.turnsRemaining {
+				queue = append([]*Sequence{next}, queue...) // Free, so visited before the rest
+			} else {
+				queue = append(queue, next)
+			}
+		}
+	}
+	distances.States = len(best)
+
+	// Counting backwards, a state's turns to the exit are the fewest of the states it leads to
+	// (plus the cost of getting there)
+	toExit := make([]int, len(best))
+	for i := range toExit {
+		toExit[i] = -1
+	}
+	type pending struct{ state, turns int }
+	backwards := []pending{}
+	for _, exit := range exits {
+		toExit[exit] = 0
+		backwards = append(backwards, pending{exit, 0})
+	}
+	for len(backwards) > 0 {
+		p := backwards[0]
+		backwards = backwards[1:]
+		if toExit[p.state] < p.turns {
+			continue // Since found again in fewer turns
+		}
+		for _, edge := range arrivals[p.state] {
+			turns := p.turns + int(edge.cost)
+			if toExit[edge.from] >= 0 && toExit[edge.from] <= turns {
+				continue
+			}
+			toExit[edge.from] = turns
+			if edge.cost == 0 {
+				backwards = append([]pending{{edge.from, turns}}, backwards...)
+			} else {
+				backwards = append(backwards, pending{edge.from, turns})
+			}
+		}
+	}
+
+	// The states visited may be further than the limit from some starts (only being within it of
+	// others) so longer routes are ignored
+	distances.Turns = make([]int, maze.TotalCells())
+	for location, start := range starts {
+		if turns := toExit[start]; turns >= 0 && turns <= int(limit) {
+			distances.Turns[location] = turns
+		} else {
+			distances.Turns[location] = -1
+		}
+	}
+	return distances
+}
+
+// Hardest is the most turns needed from any start cell (or -1 if the exit can't be reached from any)
+func (self *DistanceMap) Hardest() int {
+	hardest := -1
+	for _, turns := range self.Turns {
+		if turns > hardest {
+			hardest = turns
+		}
+	}
+	return hardest
+}
+
+// Solvable is how many start cells the exit can be reached from within the limit
+func (self *DistanceMap) Solvable() int {
+	solvable := 0
+	for _, turns := range self.Turns {
+		if turns >= 0 {
+			solvable++
+		}
+	}
+	return solvable
+}
+
+// Fprint draws the maze with the turns needed from each cell as a heatmap followed by a summary
+func (self *DistanceMap) Fprint(w io.Writer, maze *Maze, renderer Renderer, options RenderOptions) error {
+	options.Heatmap = self.Turns
+	options.ShowExit = true
+	if err := renderer.Render(w, maze, nowhere, options); err != nil {
+		return err
+	}
+
+	hardest := self.Hardest()
+	cells := []string{}
+	for location, turns := range self.Turns {
+		if turns == hardest && hardest >= 0 {
+			cells = append(cells, Command{MOVE, uint8(location), 0, 0, 0, 0}.String(self.Columns))
+		}
+	}
+	_, err := fmt.Fprintf(w, "SOLVABLE FROM %d OF %d CELL(S) WITHIN %d TURN(S), HARDEST: %d %s (%d states visited)\n",
+		self.Solvable(), len(self.Turns), self.Limit, hardest, strings.Join(cells, " "), self.States)
+	return err
+}
+
+// WriteCSV writes a row, column, turns line for each cell (turns is -1 if the exit can't be
+// reached within the limit)
+func (self *DistanceMap) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"row", "column", "turns"})
+	for location, turns := range self.Turns {
+		row, column := uint8(location)/self.Columns, uint8(location)%self.Columns
+		out.Write([]string{strconv.Itoa(int(row)), strconv.Itoa(int(column)), strconv.Itoa(turns)})
+	}
+	out.Flush()
+	return out.Error()
+}
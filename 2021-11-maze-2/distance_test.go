@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+)
+
+// solvedTurns is the fewest turns the solver needs from the start (or -1 if more than its turns
+// remaining)
+func solvedTurns(start *Sequence) int {
+	result := NewSolver().Solve(start)
+	if result.Solution == nil {
+		return -1
+	}
+	return int(result.Turns)
+}
+
+// TestSearchesAgree checks that the solver, the backward search and the distance map all find the
+// same fewest turns (or that more than the bound are needed) under every set of rules
+func TestSearchesAgree(t *testing.T) {
+	// Searching backwards (and mapping distances) goes through every arrangement of the maze within
+	// the bound, so the rules allowing the most changes are tried on smaller mazes
+	tests := []struct {
+		rules         *Rules
+		rows, columns uint8
+		bound         uint8
+	}{
+		{OctoberRules, 3, 3, 3},
+		{NovemberRules, 3, 3, 3},
+		{LabyrinthRules, 2, 3, 2},
+		{RotatingRules, 2, 3, 3},
+		{ExpressRules, 3, 3, 3},
+		{SegmentRules, 3, 3, 3},
+		{TorusRules, 3, 3, 3},
+	}
+	for _, rules := range allRules {
+		tested := false
+		for _, test := range tests {
+			tested = tested || test.rules == rules
+		}
+		if !tested {
+			t.Errorf("%s: not tested", rules)
+		}
+	}
+
+	for _, test := range tests {
+		rules := test.rules
+		for i, maze := range testMazes(rules, 8, test.rows, test.columns) {
+			start := NewSequence(maze, test.bound, rules)
+			forward := solvedTurns(start)
+
+			backward := BackwardSearch(start, test.bound)
+			if backward.Turns != forward {
+				t.Errorf("%s maze %d (%s): solver needs %d turn(s) but searching backwards %d", rules, i, maze.Pattern(), forward, backward.Turns)
+			}
+			if distances := NewDistanceMap(start.maze, rules, test.bound); distances.Turns[0] != forward {
+				t.Errorf("%s maze %d (%s): solver needs %d turn(s) but the distance map %d", rules, i, maze.Pattern(), forward, distances.Turns[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+var (
+	backgroundColor  = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	wallColor        = color.RGBA{0x1f, 0x3a, 0x5f, 0xff}
+	highlightColor   = color.RGBA{0xf3, 0xc6, 0xf0, 0xff}
+	exitColor        = color.RGBA{0xf8, 0xc8, 0xc8, 0xff}
+	playerColor      = color.RGBA{0xf2, 0xb8, 0x05, 0xff}
+	pathColor        = color.RGBA{0x2b, 0x7b, 0xe4, 0xff}
+	frameBorderColor = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	anchorColor      = color.RGBA{0xc8, 0xd4, 0xe4, 0xff}
+)
+
+// ImageOptions control how a maze is exported as an image
+type ImageOptions struct {
+	CellSize    int         // Pixels per cell
+	ShowExit    bool        // Shade the exit (bottom right) cell
+	Highlighter Highlighter // Cells to highlight (e.g. the row/column just slid)
+	Path        []uint8     // Locations the player walked through (drawn as a line)
+}
+
+// ImageFrame is a single maze state to be exported
+type ImageFrame struct {
+	Maze     *Maze
+	Location uint8
+	Options  ImageOptions
+	Label    string // Caption (only drawn in SVGs)
+}
+
+// canvas is the handful of drawing operations needed to draw a maze so that the same drawing
+// code can produce both SVG and PNG
+type canvas interface {
+	Rect(x, y, w, h float64, c color.RGBA)
+	Line(x1, y1, x2, y2, width float64, c color.RGBA)
+	Circle(cx, cy, r float64, c color.RGBA)
+	Text(x, y float64, size float64, text string)
+}
+
+// ImageFrames is a frame for each step of the sequence, highlighting what each command changed
+// and the path walked by each move
+func (self *Sequence) ImageFrames(options ImageOptions) []*ImageFrame {
+	stack := []*Sequence{}
+	for prev := self; prev != nil; prev = prev.prev {
+		stack = append([]*Sequence{prev}, stack...)
+	}
+
+	frames := []*ImageFrame{}
+	for i, s := range stack {
+		frameOptions := options
+		label := "START"
+		if i > 0 {
+			frameOptions.Highlighter = s.commandHighlighter()
+			label = fmt.Sprintf("%d. %s", i, s.CommandString())
+			if s.command.operation == MOVE {
+				frameOptions.Path = s.Path()
+			}
+		}
+		frames = append(frames, &ImageFrame{s.maze, s.location, frameOptions, label})
+	}
+	return frames
+}
+
+func (self *ImageFrame) size() (int, int) {
+	cellSize := self.Options.CellSize
+	return int(self.Maze.Columns())*cellSize + cellSize, int(self.Maze.Rows())*cellSize + cellSize
+}
+
+// cellOrigin is the top left corner of the cell at the given location (relative to the frame's top
+// left corner).  Half a cell of margin is left around the maze.
+func (self *ImageFrame) cellOrigin(location uint8) (float64, float64) {
+	size := float64(self.Options.CellSize)
+	columns := self.Maze.Columns()
+	return size/2 + float64(location%columns)*size, size/2 + float64(location/columns)*size
+}
+
+// fill is the color the cell at the given location is shaded (if any)
+func (self *ImageFrame) fill(location uint8) (color.RGBA, bool) {
+	options := self.Options
+	row, column := int(location/self.Maze.Columns()), int(location%self.Maze.Columns())
+	if options.Highlighter != nil && options.Highlighter(row, column) {
+		return highlightColor, true
+	} else if options.ShowExit && location == self.Maze.TotalCells()-1 {
+		return exitColor, true
+	} else if self.Maze.Anchored(location) {
+		return anchorColor, true
+	}
+	return color.RGBA{}, false
+}
+
+// draw draws the frame with its top left corner at the given offset
+func (self *ImageFrame) draw(c canvas, offsetX float64, offsetY float64) {
+	maze := self.Maze
+	options := self.Options
+	size := float64(options.CellSize)
+
+	width, height := self.size()
+	c.Rect(offsetX, offsetY, float64(width), float64(height), backgroundColor)
+
+	origin := func(location uint8) (float64, float64) {
+		x, y := self.cellOrigin(location)
+		return offsetX + x, offsetY + y
+	}
+
+	for location := uint8(0); location < maze.TotalCells(); location++ {
+		if fill, ok := self.fill(location); ok {
+			x, y := origin(location)
+			c.Rect(x, y, size, size, fill)
+		}
+	}
+
+	if len(options.Path) > 1 {
+		pathWidth := math.Max(2, size/6)
+		for i := 1; i < len(options.Path); i++ {
+			from, to := options.Path[i-1], options.Path[i]
+			x1, y1 := origin(from)
+			x2, y2 := origin(to)
+			if side, ok := maze.wrapSide(from, to); ok {
+				// Walked off the edge, so the line leaves one side and comes back in the other
+				dx, dy := sideDirection(side)
+				c.Line(x1+size/2, y1+size/2, x1+size/2+dx*size, y1+size/2+dy*size, pathWidth, pathColor)
+				c.Line(x2+size/2-dx*size, y2+size/2-dy*size, x2+size/2, y2+size/2, pathWidth, pathColor)
+				continue
+			}
+			c.Line(x1+size/2, y1+size/2, x2+size/2, y2+size/2, pathWidth, pathColor)
+		}
+	}
+
+	// Each cell draws its own walls, so a wall is shown wherever either side of it is closed
+	for location := uint8(0); location < maze.TotalCells(); location++ {
+		x, y := origin(location)
+		drawWalls(c, x, y, size, drawnCell(maze, location))
+	}
+
+	x, y := origin(self.Location)
+	drawPlayer(c, x, y, size)
+}
+
+func wallWidth(size float64) float64 {
+	return math.Max(2, size/8)
+}
+
+// drawnCell is the nibble of the cell at the given location with any side on the border which
+// isn't drawn open (see borderOpen) closed
+func drawnCell(maze *Maze, location uint8) byte {
+	columns := maze.Columns()
+	borders := []struct {
+		side byte
+		on   bool
+	}{
+		{8, location < columns},
+		{4, (location+1)%columns == 0},
+		{2, location >= maze.TotalCells()-columns},
+		{1, location%columns == 0},
+	}
+	b := maze.cells[location]
+	for _, border := range borders {
+		if border.on && !borderOpen(maze, location, border.side) {
+			b &^= border.side
+		}
+	}
+	return b
+}
+
+// sideDirection is the step across the frame (in cells) through the given side of a cell
+func sideDirection(side byte) (float64, float64) {
+	switch side {
+	case 8:
+		return 0, -1
+	case 4:
+		return 1, 0
+	case 2:
+		return 0, 1
+	}
+	return -1, 0
+}
+
+// drawWalls draws the closed sides of a cell (given its nibble) with its top left corner at x, y
+func drawWalls(c canvas, x float64, y float64, size float64, b byte) {
+	wall := wallWidth(size)
+	if b&8 == 0 {
+		c.Line(x, y, x+size, y, wall, wallColor)
+	}
+	if b&4 == 0 {
+		c.Line(x+size, y, x+size, y+size, wall, wallColor)
+	}
+	if b&2 == 0 {
+		c.Line(x, y+size, x+size, y+size, wall, wallColor)
+	}
+	if b&1 == 0 {
+		c.Line(x, y, x, y+size, wall, wallColor)
+	}
+}
+
+// drawPlayer draws the player in the cell with its top left corner at x, y
+func drawPlayer(c canvas, x float64, y float64, size float64) {
+	c.Circle(x+size/2, y+size/2, size/3, playerColor)
+}
+
+// contactSheetColumns is how many frames are placed side by side in a contact sheet
+func contactSheetColumns(frames int) int {
+	return int(math.Ceil(math.Sqrt(float64(frames))))
+}
+
+// layout arranges the frames in a grid (one frame is simply the frame itself), returning the
+// total size and the offset of each frame
+func layout(frames []*ImageFrame, labelHeight int) (int, int, [][2]float64) {
+	if len(frames) == 0 {
+		return 0, 0, nil
+	}
+	frameWidth, frameHeight := frames[0].size()
+	frameHeight += labelHeight
+	perRow := contactSheetColumns(len(frames))
+	if len(frames) == 1 {
+		perRow = 1
+	}
+
+	offsets := make([][2]float64, len(frames))
+	for i := range frames {
+		offsets[i] = [2]float64{
+			float64(i%perRow) * float64(frameWidth),
+			float64(i/perRow)*float64(frameHeight) + float64(labelHeight),
+		}
+	}
+	rows := (len(frames) + perRow - 1) / perRow
+	return perRow * frameWidth, rows * frameHeight, offsets
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type svgCanvas struct {
+	s strings.Builder
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (self *svgCanvas) Rect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(&self.s, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n", x, y, w, h, svgColor(c))
+}
+
+func (self *svgCanvas) Line(x1, y1, x2, y2, width float64, c color.RGBA) {
+	fmt.Fprintf(&self.s, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="%s" stroke-width="%g" stroke-linecap="square"/>`+"\n",
+		x1, y1, x2, y2, svgColor(c), width)
+}
+
+func (self *svgCanvas) Circle(cx, cy, r float64, c color.RGBA) {
+	fmt.Fprintf(&self.s, `<circle cx="%g" cy="%g" r="%g" fill="%s"/>`+"\n", cx, cy, r, svgColor(c))
+}
+
+func (self *svgCanvas) Text(x, y float64, size float64, text string) {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	fmt.Fprintf(&self.s, `<text x="%g" y="%g" font-family="monospace" font-size="%g">%s</text>`+"\n", x, y, size, text)
+}
+
+// WriteSVG writes the frames as an SVG (as a contact sheet if there is more than one)
+func WriteSVG(w io.Writer, frames ...*ImageFrame) error {
+	labelHeight := 0
+	if len(frames) > 1 {
+		labelHeight = frames[0].Options.CellSize
+	}
+	width, height, offsets := layout(frames, labelHeight)
+
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.s, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	c.Rect(0, 0, float64(width), float64(height), backgroundColor)
+	for i, frame := range frames {
+		frame.draw(c, offsets[i][0], offsets[i][1])
+		if labelHeight > 0 {
+			size := float64(labelHeight) * 0.6
+			c.Text(offsets[i][0]+float64(frame.Options.CellSize)/2, offsets[i][1]-size/3, size, frame.Label)
+		}
+	}
+	c.s.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, c.s.String())
+	return err
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type rasterCanvas struct {
+	img  *image.RGBA
+	clip image.Rectangle // Nothing is drawn outside of this (unless empty)
+}
+
+// bounds is where drawing is allowed
+func (self *rasterCanvas) bounds() image.Rectangle {
+	if self.clip.Empty() {
+		return self.img.Bounds()
+	}
+	return self.clip.Intersect(self.img.Bounds())
+}
+
+func (self *rasterCanvas) Rect(x, y, w, h float64, c color.RGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	r = r.Intersect(self.bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			self.img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+func (self *rasterCanvas) Line(x1, y1, x2, y2, width float64, c color.RGBA) {
+	if x1 == x2 || y1 == y2 {
+		// Walls are always horizontal or vertical so can be drawn as (square capped) rectangles
+		half := width / 2
+		self.Rect(math.Min(x1, x2)-half, math.Min(y1, y2)-half, math.Abs(x2-x1)+width, math.Abs(y2-y1)+width, c)
+		return
+	}
+	// Otherwise stamp circles along the line
+	steps := int(math.Ceil(math.Hypot(x2-x1, y2-y1)))
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		self.Circle(x1+(x2-x1)*t, y1+(y2-y1)*t, width/2, c)
+	}
+}
+
+func (self *rasterCanvas) Circle(cx, cy, r float64, c color.RGBA) {
+	bounds := image.Rect(int(cx-r), int(cy-r), int(cx+r)+1, int(cy+r)+1).Intersect(self.bounds())
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			dx, dy := float64(px)+0.5-cx, float64(py)+0.5-cy
+			if dx*dx+dy*dy <= r*r {
+				self.img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+func (self *rasterCanvas) Text(x, y float64, size float64, text string) {
+	// The standard library has no fonts so labels are left out of raster images
+}
+
+// RenderImage rasterizes the frames (as a contact sheet if there is more than one)
+func RenderImage(frames ...*ImageFrame) *image.RGBA {
+	width, height, offsets := layout(frames, 0)
+	c := &rasterCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.Rect(0, 0, float64(width), float64(height), frameBorderColor)
+	for i, frame := range frames {
+		frame.draw(c, offsets[i][0], offsets[i][1])
+	}
+	return c.img
+}
+
+// WritePNG writes the frames as a PNG (as a contact sheet if there is more than one)
+func WritePNG(w io.Writer, frames ...*ImageFrame) error {
+	return png.Encode(w, RenderImage(frames...))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// exportTestFrames are the frames of the solution to a small November maze
+func exportTestFrames(t *testing.T, cellSize int) []*ImageFrame {
+	verification := Verify(mustParseMaze("43020c596163c1c9", 4), 3, NovemberRules, "(1,3) U2 (3,3)")
+	if !verification.Valid() {
+		t.Fatal(verification)
+	}
+	return verification.Sequence.ImageFrames(ImageOptions{CellSize: cellSize, ShowExit: true})
+}
+
+// exportSVG is the SVG written for the frames
+func exportSVG(t *testing.T, frames ...*ImageFrame) string {
+	var s strings.Builder
+	if err := WriteSVG(&s, frames...); err != nil {
+		t.Fatal(err)
+	}
+	return s.String()
+}
+
+func TestImageFrames(t *testing.T) {
+	frames := exportTestFrames(t, 10)
+	tests := []struct {
+		label       string
+		location    uint8
+		path        []uint8
+		highlighted []uint8
+	}{
+		{"START", 0, nil, nil},
+		{"1. (1,3)", 7, []uint8{0, 1, 5, 6, 7}, []uint8{7}},
+		{"2. U2", 7, nil, []uint8{2, 6, 10, 14}},
+		{"3. (3,3)", 15, []uint8{7, 6, 10, 11, 15}, []uint8{15}},
+	}
+	if len(frames) != len(tests) {
+		t.Fatalf("%d frames, expected %d", len(frames), len(tests))
+	}
+	for i, test := range tests {
+		frame := frames[i]
+		if frame.Label != test.label || frame.Location != test.location {
+			t.Errorf("Frame %d: %s at %d, expected %s at %d", i, frame.Label, frame.Location, test.label, test.location)
+		}
+		if fmt.Sprint(frame.Options.Path) != fmt.Sprint(test.path) {
+			t.Errorf("Frame %d: path %v, expected %v", i, frame.Options.Path, test.path)
+		}
+		highlighted := []uint8{}
+		for location := uint8(0); location < frame.Maze.TotalCells(); location++ {
+			if frame.Options.Highlighter != nil && frame.Options.Highlighter(int(location/4), int(location%4)) {
+				highlighted = append(highlighted, location)
+			}
+		}
+		if fmt.Sprint(highlighted) != fmt.Sprint(test.highlighted) {
+			t.Errorf("Frame %d: highlighted %v, expected %v", i, highlighted, test.highlighted)
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	frames := exportTestFrames(t, 10)
+	tests := []struct {
+		frames []*ImageFrame
+		size   string
+		lines  int // Closed sides of cells and paths walked
+		labels int
+	}{
+		// 4x4 cells of 10 pixels with half a cell of margin all round
+		{frames[:1], `width="50" height="50"`, 40, 0},
+		{frames[1:2], `width="50" height="50"`, 44, 0},
+		// A contact sheet of 2x2 frames each with a label above
+		{frames, `width="100" height="120"`, 4*40 + 8, 4},
+	}
+	for _, test := range tests {
+		svg := exportSVG(t, test.frames...)
+		if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") || !strings.Contains(svg, test.size) {
+			t.Errorf("%d frame(s): unexpected SVG\n%s", len(test.frames), svg)
+		}
+		counts := []struct {
+			element string
+			want    int
+		}{
+			{"<line ", test.lines},
+			{"<circle ", len(test.frames)},
+			{"<text ", test.labels},
+		}
+		for _, count := range counts {
+			if got := strings.Count(svg, count.element); got != count.want {
+				t.Errorf("%d frame(s): %d %s elements, expected %d", len(test.frames), got, count.element, count.want)
+			}
+		}
+	}
+	if !strings.Contains(exportSVG(t, frames...), ">3. (3,3)</text>") {
+		t.Error("Last frame not labelled")
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	frames := exportTestFrames(t, 16)
+	var b bytes.Buffer
+	if err := WritePNG(&b, frames[0]); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 80 || size.Y != 80 {
+		t.Fatalf("Image is %v, expected 80x80", size)
+	}
+
+	// Cells are 16 pixels with 8 pixels of margin, so cell (r,c) is centred at (16c+16, 16r+16)
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"margin", 2, 2, backgroundColor},
+		{"player", 16, 16, playerColor},
+		{"exit", 64 + 4, 64 + 4, exitColor},
+		{"top wall", 16, 8, wallColor},
+		{"opening between (0,0) and (0,1)", 24, 16, backgroundColor},
+		{"wall between (0,0) and (1,0)", 16, 24, wallColor},
+	}
+	for _, test := range tests {
+		r, g, b, a := img.At(test.x, test.y).RGBA()
+		got := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+		if got != test.want {
+			t.Errorf("%s at (%d,%d): %v, expected %v", test.name, test.x, test.y, got, test.want)
+		}
+	}
+
+	sheet := RenderImage(frames...)
+	if size := sheet.Bounds().Size(); size.X != 160 || size.Y != 160 {
+		t.Errorf("Contact sheet is %v, expected 160x160", size)
+	}
+}
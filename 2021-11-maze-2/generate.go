@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// GeneratorOptions control the random mazes made by Generate
+type GeneratorOptions struct {
+	Rows         uint8
+	Columns      uint8
+	Turns        uint8 // Exactly how many turns the maze must need
+	Rules        *Rules
+	WallDensity  float64       // Chance of each wall being closed (from 0 to 1)
+	OneSided     bool          // Close each side of a wall independently (so the sides may disagree)
+	Seed         int64         // The same seed (and options) always makes the same maze
+	Attempts     int           // Give up after trying this many mazes (no limit if 0)
+	SolveTimeout time.Duration // Mazes which can't be solved in time are skipped (no limit if 0)
+}
+
+// Generated is a maze made by Generate along with the solution proving how many turns it needs
+type Generated struct {
+	Scenario *Scenario
+	Result   *SolveResult
+	Attempts int // How many mazes were tried
+}
+
+// Generate makes random mazes until one is found which needs exactly the given number of turns.
+// Every maze is solved, and since the solver tries every smaller budget first, a solution using
+// the given turns proves that there is none using fewer.
+func Generate(options GeneratorOptions) (*Generated, error) {
+	if options.Rows == 0 || options.Columns == 0 || int(options.Rows)*int(options.Columns) > 255 {
+		return nil, fmt.Errorf("Maze must have between 1 and 255 cells")
+	}
+	r := rand.New(rand.NewSource(options.Seed))
+
+	for attempt := 1; options.Attempts == 0 || attempt <= options.Attempts; attempt++ {
+		maze := randomMaze(r, options)
+		solver := NewSolver()
+		solver.Timeout = options.SolveTimeout
+		result := solver.Solve(NewSequence(maze, options.Turns, options.Rules))
+
+		if result.Solution != nil && result.Turns == options.Turns {
+			scenario := &Scenario{Turns: options.Turns, Columns: options.Columns, Rows: options.Rows, MazePattern: maze.Pattern(), Rules: options.Rules.Name}
+			return &Generated{scenario, result, attempt}, nil
+		}
+	}
+	return nil, fmt.Errorf("No maze needing exactly %d turn(s) found in %d attempt(s)", options.Turns, options.Attempts)
+}
+
+// randomMaze opens each wall between neighbouring cells at random.  The walls around the border
+// of the maze are closed unless the player can walk around the edge, when the opposite sides are
+// opened at random as if they were neighbours.  The spare tile (if the rules have one) is any tile
+// at all.
+func randomMaze(r *rand.Rand, options GeneratorOptions) *Maze {
+	columns := options.Columns
+	cells := make([]byte, int(options.Rows)*int(columns))
+	open := func() bool {
+		return r.Float64() >= options.WallDensity
+	}
+	// connect opens the given sides of two neighbouring cells
+	connect := func(location int, side byte, neighbour int, neighbourSide byte) {
+		if options.OneSided {
+			if open() {
+				cells[location] |= side
+			}
+			if open() {
+				cells[neighbour] |= neighbourSide
+			}
+		} else if open() {
+			cells[location] |= side
+			cells[neighbour] |= neighbourSide
+		}
+	}
+
+	for location := range cells {
+		if (location+1)%int(columns) != 0 {
+			connect(location, 4, location+1, 1) // East
+		}
+		if location+int(columns) < len(cells) {
+			connect(location, 2, location+int(columns), 8) // South
+		}
+	}
+	if options.Rules != nil && options.Rules.WrapMoves {
+		rows := int(options.Rows)
+		for row := 0; columns > 1 && row < rows; row++ {
+			connect(row*int(columns)+int(columns)-1, 4, row*int(columns), 1) // East around to west
+		}
+		for column := 0; rows > 1 && column < int(columns); column++ {
+			connect((rows-1)*int(columns)+column, 2, column, 8) // South around to north
+		}
+	}
+	maze := &Maze{cells, columns, 0, false, nil, false}
+	if options.Rules != nil && options.Rules.SpareTile {
+		maze.spare, maze.hasSpare = byte(r.Intn(16)), true
+	}
+	return maze
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// testMazes are random mazes to play by the given rules (the same ones each time)
+func testMazes(rules *Rules, count int, rows uint8, columns uint8) []*Maze {
+	r := rand.New(rand.NewSource(int64(len(rules.Name))))
+	mazes := []*Maze{}
+	for i := 0; i < count; i++ {
+		mazes = append(mazes, randomMaze(r, GeneratorOptions{Rows: rows, Columns: columns, Rules: rules, WallDensity: 0.5}))
+	}
+	return mazes
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		rows, columns, turns uint8
+		oneSided             bool
+		seed                 int64
+	}{
+		{4, 4, 1, false, 1},
+		{4, 4, 3, false, 2},
+		{3, 5, 2, true, 3},
+		{5, 3, 4, false, 4},
+	}
+	for _, test := range tests {
+		options := GeneratorOptions{
+			Rows:        test.rows,
+			Columns:     test.columns,
+			Turns:       test.turns,
+			Rules:       NovemberRules,
+			WallDensity: 0.5,
+			OneSided:    test.oneSided,
+			Seed:        test.seed,
+		}
+		generated, err := Generate(options)
+		if err != nil {
+			t.Errorf("%+v: %s", test, err)
+			continue
+		}
+		scenario := generated.Scenario
+		if scenario.Rows != test.rows || scenario.Columns != test.columns || scenario.Turns != test.turns || scenario.Rules != "november" {
+			t.Errorf("%+v: generated %+v", test, scenario)
+		}
+		if generated.Result.Turns != test.turns {
+			t.Errorf("%+v: solved in %d turn(s)", test, generated.Result.Turns)
+		}
+
+		// The same seed always makes the same maze (after the same number of attempts)
+		again, err := Generate(options)
+		if err != nil || again.Scenario.MazePattern != scenario.MazePattern || again.Attempts != generated.Attempts {
+			t.Errorf("%+v: made %s after %d attempt(s) then %+v (%v)", test, scenario.MazePattern, generated.Attempts, again, err)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []GeneratorOptions{
+		{Rows: 0, Columns: 4, Turns: 1, Rules: NovemberRules},
+		{Rows: 16, Columns: 16, Turns: 1, Rules: NovemberRules},
+		{Rows: 1, Columns: 1, Turns: 1, Rules: NovemberRules, Attempts: 3}, // Already at the exit
+	}
+	for _, options := range tests {
+		if generated, err := Generate(options); err == nil {
+			t.Errorf("%+v: generated %+v", options, generated.Scenario)
+		}
+	}
+}
+
+// TestRandomMaze checks that the border is closed (unless the player can walk around the edge, when
+// opposite sides are opened in pairs), and that walls only disagree if one sided
+func TestRandomMaze(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, rules := range []*Rules{NovemberRules, TorusRules} {
+		for _, oneSided := range []bool{false, true} {
+			mismatched, border := 0, 0
+			for i := 0; i < 20; i++ {
+				maze := randomMaze(r, GeneratorOptions{Rows: 4, Columns: 5, Rules: rules, WallDensity: 0.5, OneSided: oneSided})
+				for location, cell := range maze.cells {
+					row, column := location/5, location%5
+					// Neighbours to the east and south (around the edge if on the border)
+					east, south := maze.cells[row*5+(column+1)%5], maze.cells[(row+1)%4*5+column]
+					if (column < 4 || rules.WrapMoves) && (cell&4 != 0) != (east&1 != 0) {
+						mismatched++
+					}
+					if (row < 3 || rules.WrapMoves) && (cell&2 != 0) != (south&8 != 0) {
+						mismatched++
+					}
+					if (row == 0 && cell&8 != 0) || (row == 3 && cell&2 != 0) || (column == 0 && cell&1 != 0) || (column == 4 && cell&4 != 0) {
+						border++
+					}
+				}
+			}
+			if (mismatched > 0) != oneSided || (border > 0) != rules.WrapMoves {
+				t.Errorf("%s one sided %v: %d wall(s) mismatched and %d border opening(s)", rules, oneSided, mismatched, border)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"time"
+)
+
+// AnimationOptions control how a solution is animated
+type AnimationOptions struct {
+	CellSize    int           // Pixels per cell
+	SlideFrames int           // Frames used to animate each slide (1 simply jumps to the result)
+	StepDelay   time.Duration // Between the frames of a slide and between each cell walked by a move
+	Pause       time.Duration // How long the maze is held still after each command
+}
+
+func DefaultAnimationOptions() AnimationOptions {
+	return AnimationOptions{CellSize: 32, SlideFrames: 8, StepDelay: 50 * time.Millisecond, Pause: time.Second}
+}
+
+// animationPalette is every color used to draw a maze (so frames can be paletted without loss)
+var animationPalette = color.Palette{
+	backgroundColor, wallColor, highlightColor, exitColor, playerColor, pathColor, frameBorderColor, anchorColor,
+}
+
+// Animate draws the sequence from the start: each slide shifts its row/column across and each move
+// walks the player cell by cell along the shortest path to its destination.  The animation loops
+// forever, holding the final state for a few pauses before starting again.
+func (self *Sequence) Animate(options AnimationOptions) *gif.GIF {
+	stack := []*Sequence{}
+	for prev := self; prev != nil; prev = prev.prev {
+		stack = append([]*Sequence{prev}, stack...)
+	}
+
+	anim := &gif.GIF{}
+	add := func(img *image.RGBA, delay time.Duration) {
+		paletted := image.NewPaletted(img.Bounds(), animationPalette)
+		draw.Draw(paletted, img.Bounds(), img, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond))) // In 100ths of a second
+	}
+
+	imageOptions := ImageOptions{CellSize: options.CellSize, ShowExit: true}
+	add(RenderImage(&ImageFrame{Maze: stack[0].maze, Location: stack[0].location, Options: imageOptions}), options.Pause)
+	for _, s := range stack[1:] {
+		prev := s.prev
+		final := imageOptions
+		final.Highlighter = s.commandHighlighter()
+		if s.command.operation == MOVE {
+			path := s.Path()
+			for i := 1; i < len(path)-1; i++ {
+				frameOptions := imageOptions
+				frameOptions.Path = path[:i+1]
+				add(RenderImage(&ImageFrame{Maze: s.maze, Location: path[i], Options: frameOptions}), options.StepDelay)
+			}
+			final.Path = path
+		} else if s.command.operation != ROTATE && s.command.end == 0 && prev.maze.anchors == nil {
+			// Whole rows/columns are shown sliding along (anything else simply jumps to the result)
+			for i := 1; i < options.SlideFrames; i++ {
+				progress := float64(i) / float64(options.SlideFrames)
+				add(prev.slideImage(s.command, progress, imageOptions), options.StepDelay)
+			}
+		}
+		add(RenderImage(&ImageFrame{Maze: s.maze, Location: s.location, Options: final}), options.Pause)
+	}
+	anim.Delay[len(anim.Delay)-1] *= 3
+	return anim
+}
+
+// slideImage draws the sequence part way through the given slide (with progress between 0 and 1).
+// The cells of the row/column are shifted across with the cell which wraps around shown both
+// leaving one side and entering the other.
+func (self *Sequence) slideImage(slide Command, progress float64, options ImageOptions) *image.RGBA {
+	maze := self.maze
+	columns := maze.Columns()
+	horizontal := slide.operation == SLIDE_RIGHT || slide.operation == SLIDE_LEFT
+	moving := func(location uint8) bool {
+		if horizontal {
+			return location/columns == slide.argument
+		}
+		return location%columns == slide.argument
+	}
+	options.Highlighter = func(row int, column int) bool {
+		return moving(uint8(row)*columns + uint8(column))
+	}
+	frame := &ImageFrame{Maze: maze, Location: self.location, Options: options}
+
+	width, height := frame.size()
+	c := &rasterCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	c.Rect(0, 0, float64(width), float64(height), backgroundColor)
+	size := float64(options.CellSize)
+	for location := uint8(0); location < maze.TotalCells(); location++ {
+		if fill, ok := frame.fill(location); ok && !moving(location) {
+			x, y := frame.cellOrigin(location)
+			c.Rect(x, y, size, size, fill)
+		}
+	}
+
+	// Everything in the row/column is shifted (and drawn again a whole row/column behind so that the
+	// cell wrapping around appears on the other side)
+	direction := 1.0
+	if slide.operation == SLIDE_LEFT || slide.operation == SLIDE_UP {
+		direction = -1
+	}
+	distance := 1.0
+	if slide.distance > 1 {
+		distance = float64(slide.distance) // Shifted further (still less than a whole row/column)
+	}
+	lineX, lineY := frame.cellOrigin(slide.argument * columns)
+	lineWidth, lineHeight := float64(columns)*size, size
+	shiftX, shiftY := progress*size*distance*direction, 0.0
+	wrapX, wrapY := -lineWidth*direction, 0.0
+	if !horizontal {
+		lineX, lineY = frame.cellOrigin(slide.argument)
+		lineWidth, lineHeight = size, float64(maze.Rows())*size
+		shiftX, shiftY = 0, progress*size*distance*direction
+		wrapX, wrapY = 0, -lineHeight*direction
+	}
+
+	// Walls on the sides of the row/column are allowed to spill over into the neighbouring cells
+	spill := wallWidth(size) / 2
+	clip := image.Rect(int(math.Round(lineX)), int(math.Round(lineY-spill)), int(math.Round(lineX+lineWidth)), int(math.Round(lineY+lineHeight+spill)))
+	if !horizontal {
+		clip = image.Rect(int(math.Round(lineX-spill)), int(math.Round(lineY)), int(math.Round(lineX+lineWidth+spill)), int(math.Round(lineY+lineHeight)))
+	}
+	c.clip = clip
+
+	offsets := [][2]float64{{shiftX, shiftY}, {shiftX + wrapX, shiftY + wrapY}}
+	for _, offset := range offsets {
+		for location := uint8(0); location < maze.TotalCells(); location++ {
+			if moving(location) {
+				x, y := frame.cellOrigin(location)
+				c.Rect(x+offset[0], y+offset[1], size, size, highlightColor)
+			}
+		}
+	}
+	for i, offset := range offsets {
+		for location := uint8(0); location < maze.TotalCells(); location++ {
+			if moving(location) {
+				cell := maze.cells[location]
+				if spare, ok := maze.Spare(); ok && i > 0 && maze.SlideLocation(slide, location) == maze.entrance(slide) {
+					cell = rotateNibble(spare, slide.rotation) // The spare is pushed in instead of the cell wrapping around
+				}
+				x, y := frame.cellOrigin(location)
+				drawWalls(c, x+offset[0], y+offset[1], size, cell)
+			}
+		}
+	}
+	carried := self.rules.CarryPlayer && moving(self.location)
+	if carried {
+		for _, offset := range offsets {
+			x, y := frame.cellOrigin(self.location)
+			drawPlayer(c, x+offset[0], y+offset[1], size)
+		}
+	}
+	c.clip = image.Rectangle{}
+
+	for location := uint8(0); location < maze.TotalCells(); location++ {
+		if !moving(location) {
+			x, y := frame.cellOrigin(location)
+			drawWalls(c, x, y, size, drawnCell(maze, location))
+		}
+	}
+	if !carried {
+		x, y := frame.cellOrigin(self.location)
+		drawPlayer(c, x, y, size)
+	}
+	return c.img
+}
+
+// WriteGIF writes an animation of the sequence from the start
+func WriteGIF(w io.Writer, sequence *Sequence, options AnimationOptions) error {
+	return gif.EncodeAll(w, sequence.Animate(options))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+func TestAnimate(t *testing.T) {
+	verification := Verify(mustParseMaze("43020c596163c1c9", 4), 3, NovemberRules, "(1,3) U2 (3,3)")
+	if !verification.Valid() {
+		t.Fatal(verification)
+	}
+	// The moves walk 4 cells each (so 3 frames before arriving) and the slide is animated between
+	// the maze before and after it
+	tests := []struct {
+		options AnimationOptions
+		delays  string // In 100ths of a second
+	}{
+		{DefaultAnimationOptions(), "[100 5 5 5 100 5 5 5 5 5 5 5 100 5 5 5 300]"},
+		{AnimationOptions{CellSize: 8, SlideFrames: 1, StepDelay: 20 * time.Millisecond, Pause: 500 * time.Millisecond}, "[50 2 2 2 50 50 2 2 2 150]"},
+		{AnimationOptions{CellSize: 8, SlideFrames: 3, StepDelay: 0, Pause: 0}, "[0 0 0 0 0 0 0 0 0 0 0 0]"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := WriteGIF(&b, verification.Sequence, test.options); err != nil {
+			t.Fatal(err)
+		}
+		anim, err := gif.DecodeAll(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if delays := fmt.Sprint(anim.Delay); delays != test.delays {
+			t.Errorf("%+v: delays %s, expected %s", test.options, delays, test.delays)
+		}
+		size := anim.Image[0].Bounds().Size()
+		if side := 5 * test.options.CellSize; size.X != side || size.Y != side {
+			t.Errorf("%+v: frames are %v", test.options, size)
+		}
+	}
+}
+
+// TestSlideImage checks that the row or column being slid is covered all the way along part way
+// through the slide (with the cell wrapping round drawn on both sides)
+func TestSlideImage(t *testing.T) {
+	start := NewSequence(mustParseMaze("43020c596163c1c9", 4), 3, NovemberRules)
+	options := ImageOptions{CellSize: 16}
+	tests := []Command{
+		{SLIDE_RIGHT, 1, 0, 0, 0, 0},
+		{SLIDE_LEFT, 2, 0, 0, 0, 0},
+		{SLIDE_DOWN, 1, 0, 0, 0, 0},
+		{SLIDE_UP, 3, 0, 0, 0, 0},
+		{SLIDE_RIGHT, 1, 0, 3, 0, 0}, // Drawn whether or not the rules allow it
+		{SLIDE_UP, 0, 0, 2, 0, 0},
+	}
+	for _, slide := range tests {
+		// The line slid (4 cells of 16 pixels after 8 pixels of margin)
+		line := image.Rect(8, 8+16*int(slide.argument), 8+64, 8+16*int(slide.argument)+16)
+		if slide.operation == SLIDE_DOWN || slide.operation == SLIDE_UP {
+			line = image.Rect(8+16*int(slide.argument), 8, 8+16*int(slide.argument)+16, 8+64)
+		}
+		for _, progress := range []float64{0.25, 0.5, 0.75} {
+			img := start.slideImage(slide, progress, options)
+			gaps := 0
+			for y := line.Min.Y; y < line.Max.Y; y++ {
+				for x := line.Min.X; x < line.Max.X; x++ {
+					if img.RGBAAt(x, y) == backgroundColor {
+						gaps++
+					}
+				}
+			}
+			if gaps > 0 {
+				t.Errorf("%s at %v: %d pixel(s) of the line left empty", slide.String(4), progress, gaps)
+			}
+		}
+	}
+}
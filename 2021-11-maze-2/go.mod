@@ -4,22 +4,13 @@ go 1.17
 
 require (
 	github.com/gammazero/workerpool v1.1.2
-	github.com/ghetzel/go-stockutil v1.9.13
 	github.com/gookit/color v1.5.0
+	golang.org/x/term v0.1.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/fatih/structs v1.0.0 // indirect
 	github.com/gammazero/deque v0.1.0 // indirect
-	github.com/ghetzel/uuid v0.0.0-20171129191014-dec09d789f3d // indirect
-	github.com/hashicorp/errwrap v1.0.0 // indirect
-	github.com/hashicorp/go-multierror v1.0.0 // indirect
-	github.com/jbenet/go-base58 v0.0.0-20150317085156-6237cf65f3a6 // indirect
-	github.com/jdkato/prose v1.1.0 // indirect
-	github.com/juliangruber/go-intersect v1.0.0 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
-	golang.org/x/sys v0.0.0-20210330210617-4fbd30eecc44 // indirect
-	gopkg.in/neurosnap/sentences.v1 v1.0.6 // indirect
-	k8s.io/client-go v11.0.0+incompatible // indirect
+	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
 )
@@ -1,33 +1,24 @@
+//go:build !js
+
 package main
 
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"regexp"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 /////////////////////////////////////////////////////////////////////////////////////////////////////
 
-// Scenario is a maze to be solved within the given number of turns
-type Scenario struct {
-	Turns       uint8
-	Columns     uint8
-	Rows        uint8
-	MazePattern string
-}
-
-func (self *Scenario) startSequence() *Sequence {
-	maze := NewMaze(self.MazePattern, self.Columns)
-	return NewSequence(maze, self.Turns)
-}
-
 func copyFileIfNotExist(src string, dst string) {
 	_, err := os.Stat(dst)
 	if !os.IsNotExist(err) {
@@ -76,102 +67,790 @@ func loadScenario() *Scenario {
 
 /////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func parseArgs() (*Maze, uint8) {
-	if len(os.Args) != 4 {
+// parseArgs reads the scenario given as PATTERN DIMENSIONS TURNS (with the anchors from
+// anchorFlags) along with its start
+func parseArgs(args []string, rules *Rules, anchor func(*Scenario)) (*Scenario, *Sequence) {
+	if len(args) != 3 {
 		usage()
 	}
+	scenario, err := ParseScenario(args[0], args[1], args[2], rules.Name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		usage()
+	}
+	anchor(scenario)
+	start, err := scenario.Sequence()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return scenario, start
+}
 
-	mazePattern := strings.ToLower(os.Args[1])
-	mazePatternIsValid, _ := regexp.Match("^[[:xdigit:]]+$", []byte(mazePattern))
-	if !mazePatternIsValid {
-		fmt.Fprintf(os.Stderr, "Maze pattern must be hex string\n")
+// rulesFlag adds a -rules option to the given flags
+func rulesFlag(flags *flag.FlagSet) func() *Rules {
+	name := flags.String("rules", NovemberRules.Name, "Rules to play by (october, november, labyrinth, rotating, express, segments or torus)")
+	return func() *Rules {
+		rules, err := RulesByName(*name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			usage()
+		}
+		return rules
+	}
+}
+
+// anchorFlags adds -anchors and -skip-anchors options to the given flags.  The returned function
+// anchors those cells of a scenario (unless none were given).
+func anchorFlags(flags *flag.FlagSet) func(*Scenario) {
+	cells := flags.String("anchors", "", "Cells which never move, e.g. \"(1,1) (3,3)\"")
+	skip := flags.Bool("skip-anchors", false, "Slide the other cells around the anchors (instead of not sliding through them)")
+	return func(scenario *Scenario) {
+		if *cells == "" {
+			return
+		}
+		scenario.Anchors, scenario.SkipAnchors = strings.Fields(*cells), *skip
+		if _, err := scenario.Sequence(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			usage()
+		}
+	}
+}
+
+// renderFlags adds -style and -coords options to the given flags
+func renderFlags(flags *flag.FlagSet) func() (Renderer, RenderOptions) {
+	style := flags.String("style", "block", "How to draw the maze (block, ascii or compact)")
+	coordinates := flags.Bool("coords", false, "Label the rows and columns of the maze")
+	return func() (Renderer, RenderOptions) {
+		renderer, err := RendererByName(*style)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			usage()
+		}
+		options := StdoutRenderOptions()
+		options.Coordinates = *coordinates
+		return renderer, options
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "USAGE: maze-ibm [-rules RULES] [-anchors CELLS] [-skip-anchors] [-style STYLE] [-coords] [-session FILE] [PATTERN] [DIMENSIONS] [TURNS]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm replay [-rules RULES] [-style STYLE] [-coords] [-plain] [TRANSCRIPT]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm export [-rules RULES] [-anchors CELLS] [-skip-anchors] [-format svg|png|gif] [-cell PIXELS] [-steps|-sheet] [-delay D] [-pause D] [-slide-frames N] [-o FILE] [PATTERN] [DIMENSIONS] [TURNS] [SOLUTION]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm verify [-rules RULES] [-anchors CELLS] [-skip-anchors] [-style STYLE] [-coords] [PATTERN] [DIMENSIONS] [TURNS] [SOLUTION]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm validate [-normalise] [PATTERN] [DIMENSIONS] [SOLUTION]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm stats [-rules RULES] [-anchors CELLS] [-skip-anchors] [-heatmap TURNS] [PATTERN] [DIMENSIONS]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm distances [-rules RULES] [-anchors CELLS] [-skip-anchors] [-style STYLE] [-coords] [-format text|csv|json] [PATTERN] [DIMENSIONS] [TURNS]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm solve [-rules RULES] [-anchors CELLS] [-skip-anchors] [-style STYLE] [-coords] [-format text|json|jsonl] [-patterns] [-timeout D] [-backward N] [-certificate FILE] [PATTERN] [DIMENSIONS] [TURNS]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm solve [-rules RULES] [-format text|json|jsonl] [-patterns] [-timeout D] [-backward N] -batch FILE\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm generate [-rules RULES] [-density P] [-one-sided] [-seed N] [-attempts N] [-timeout D] [-o FILE] [DIMENSIONS] [TURNS]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm design [-rules RULES] [-max-turns N] [-time D] [-solve-timeout D] [-workers N] [-temperature T] [-cooling C] [-keep N] [-seed N] [-checkpoint FILE] [DIMENSIONS] [PATTERN]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm serve [-addr HOST:PORT] [-max-solve-time DURATION]\n")
+	fmt.Fprintf(os.Stderr, "       maze-ibm api < CALLS\n")
+	os.Exit(1)
+}
+
+func replayMain(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	plain := flags.Bool("plain", false, "Print the transcript with color codes stripped instead of replaying it")
+	rules := rulesFlag(flags)
+	render := renderFlags(flags)
+	flags.Parse(args)
+	renderer, renderOptions := render()
+
+	in := os.Stdin
+	if flags.NArg() > 1 {
+		usage()
+	} else if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if *plain {
+		dat, err := io.ReadAll(in)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(StripColorCodes(string(dat)))
+		return
+	}
+
+	transcript, err := ReadTranscript(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	steps := len(transcript.Frames) - 1
+	sequence, mismatch := transcript.Replay(steps, rules())
+	if mismatch != nil {
+		fmt.Println("MISMATCH:", mismatch)
+		sequence.Fdraw(os.Stdout, renderer, renderOptions)
+		os.Exit(2)
+	}
+	fmt.Println("OK:", steps, "step(s) replayed")
+	if sequence.IsFound() {
+		fmt.Println("SOLUTION:", colorize("green", sequence.SolutionString()))
+	}
+}
+
+func verifyMain(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	anchor := anchorFlags(flags)
+	render := renderFlags(flags)
+	flags.Parse(args)
+	renderer, renderOptions := render()
+
+	if flags.NArg() < 4 {
 		usage()
 	}
+	scenario, start := parseArgs(flags.Args()[:3], rules(), anchor)
+	solution := strings.Join(flags.Args()[3:], " ")
+
+	verification := Verify(start.maze, scenario.Turns, rules(), solution)
+	verification.Sequence.Fdraw(os.Stdout, renderer, renderOptions)
+	if verification.Valid() {
+		fmt.Println(colorize("green", verification))
+	} else {
+		fmt.Println(colorize("red", verification))
+		os.Exit(2)
+	}
+}
 
-	dimensions := strings.SplitN(os.Args[2], "x", 2)
-	if len(dimensions) != 2 {
-		fmt.Fprintf(os.Stderr, "Dimensions must be two digits, e.g. 4x5\n")
+func validateMain(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	normalise := flags.Bool("normalise", false, "Print the pattern with every mismatched opening closed")
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
 		usage()
 	}
-	rows := parseUint8(dimensions[0])
-	columns := parseUint8(dimensions[1])
-	if int(rows*columns) != len(mazePattern) {
-		fmt.Fprintf(os.Stderr, "Maze pattern is not of size %s\n", os.Args[2])
+	rows, columns, err := ParseDimensions(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		usage()
+	}
+	maze, err := ParseMaze(flags.Arg(0), columns)
+	if err != nil || maze.Rows() != rows {
+		fmt.Fprintf(os.Stderr, "Maze pattern doesn't match %s\n", flags.Arg(1))
 		usage()
 	}
 
-	turns := parseUint8(os.Args[3])
+	validation := ValidateMaze(maze)
+	fmt.Printf("STATIC: %d asymmetric wall(s), %d border opening(s)\n", len(validation.Asymmetric), len(validation.Border))
+	for _, mismatch := range append(validation.Asymmetric, validation.Border...) {
+		fmt.Println("  " + mismatch.String(columns))
+	}
 
-	return NewMaze(mazePattern, columns), turns
+	slides, err := ValidateSlides(maze, strings.Join(flags.Args()[2:], " "))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SLIDE-INDUCED:")
+	for _, slide := range slides {
+		fmt.Println("  " + slide.String())
+	}
+
+	if *normalise {
+		fmt.Println("NORMALISED:", maze.Normalise().Pattern())
+	} else if !validation.Valid() {
+		os.Exit(2)
+	}
 }
 
-func parseUint8(s string) uint8 {
-	i, err := strconv.ParseUint(s, 10, 8)
+func statsMain(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	anchor := anchorFlags(flags)
+	heatmap := flags.Uint("heatmap", 0, "Show the fewest turns to reach each cell (searching up to this many turns)")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 || *heatmap > 255 {
+		usage()
+	}
+	rows, columns, err := ParseDimensions(flags.Arg(1))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		usage()
 	}
-	return uint8(i)
+	scenario := &Scenario{Columns: columns, Rows: rows, MazePattern: strings.ToLower(flags.Arg(0)), Rules: rules().Name}
+	anchor(scenario)
+	start, err := scenario.Sequence()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		usage()
+	}
+	NewMazeStats(start.maze, start.rules, uint8(*heatmap)).Fprint(os.Stdout, start.maze)
 }
 
-func usage() {
-	fmt.Fprintf(os.Stderr, "USAGE: maze-ibm [PATTERN] [DIMENSIONS] [TURNS]\n")
-	os.Exit(1)
+func distancesMain(args []string) {
+	flags := flag.NewFlagSet("distances", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	anchor := anchorFlags(flags)
+	render := renderFlags(flags)
+	format := flags.String("format", "text", "Output format (text for a heatmap, csv or json)")
+	flags.Parse(args)
+	renderer, renderOptions := render()
+
+	if flags.NArg() != 3 || (*format != "text" && *format != "csv" && *format != "json") {
+		usage()
+	}
+	scenario, start := parseArgs(flags.Args(), rules(), anchor)
+
+	var err error
+	distances := NewDistanceMap(start.maze, start.rules, scenario.Turns)
+	switch *format {
+	case "csv":
+		err = distances.WriteCSV(os.Stdout)
+	case "json":
+		var dat []byte
+		if dat, err = json.MarshalIndent(distances, "", "  "); err == nil {
+			fmt.Println(string(dat))
+		}
+	default:
+		err = distances.Fprint(os.Stdout, start.maze, renderer, renderOptions)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func main() {
-	runtime.GOMAXPROCS(16)
+func playMain(args []string) {
+	flags := flag.NewFlagSet("maze-ibm", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	anchor := anchorFlags(flags)
+	render := renderFlags(flags)
+	sessionPath := flags.String("session", "", "Session file to continue (if it exists) and to save to")
+	hintTimeout := flags.Duration("hint-time", 30*time.Second, "Time limit when searching for a hint")
+	flags.Parse(args)
+	renderer, renderOptions := render()
+
+	var session *Session
+	if *sessionPath != "" && flags.NArg() == 0 {
+		var err error
+		if session, err = LoadSession(*sessionPath); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		scenario, _ := parseArgs(flags.Args(), rules(), anchor)
+		var err error
+		if session, err = NewSession(scenario); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if IsTerminal() {
+		tui := NewTUI(session, *sessionPath, *hintTimeout)
+		tui.SetStyle(renderer, renderOptions)
+		if _, err := tui.Run(); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		playLines(session, *hintTimeout, renderer, renderOptions)
+	}
 
-	startMaze, turns := parseArgs()
-	startSequence := NewSequence(startMaze, turns)
+	if *sessionPath != "" {
+		if err := session.Save(*sessionPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	session.Sequence().FprintSummary(os.Stdout, renderer, renderOptions)
+}
 
+// playLines plays the session by reading commands line by line (e.g. from a pipe)
+func playLines(session *Session, hintTimeout time.Duration, renderer Renderer, renderOptions RenderOptions) {
 	reader := bufio.NewReader(os.Stdin)
-	ws := regexp.MustCompile(`\s`)
-	startSequence.PrintSummary()
-
-	for turn := uint8(0); turn < turns; {
-		fmt.Print("TURN #", turn, " >> ")
-		text, _ := reader.ReadString('\n')
-		if text == "exit\n" {
-			os.Exit(0)
-		} else if text == "undo\n" {
-			startSequence = startSequence.prev
-			turn--
-		} else {
-			for _, s := range strings.Split(text, " ") {
-				fmt.Println("|", ws.ReplaceAllString(s, ""), "|")
-				cmd := startSequence.CommandFromString(ws.ReplaceAllString(s, ""))
-				if startSequence.CanApply(cmd) {
-					startSequence = startSequence.Apply(cmd)
-					turn++
-				} else {
-					fmt.Println("ACTION(S) NOT ALLOWED")
+	session.Sequence().FprintSummary(os.Stdout, renderer, renderOptions)
+
+	for !session.Sequence().IsFound() {
+		fmt.Print("TURN #", session.TurnsUsed(), " >> ")
+		text, err := reader.ReadString('\n')
+		fields := strings.Fields(text)
+		if err != nil && len(fields) == 0 {
+			fmt.Println()
+			return
+		}
+
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "exit":
+			return
+		case fields[0] == "undo":
+			if !session.Undo() {
+				fmt.Println("NOTHING TO UNDO")
+			}
+		case fields[0] == "redo":
+			if !session.Redo() {
+				fmt.Println("NOTHING TO REDO")
+			}
+		case fields[0] == "branch":
+			// With no argument list the branches, otherwise choose which one redo follows
+			branches := session.Branches()
+			if len(fields) == 1 {
+				for i, command := range branches {
+					fmt.Println("BRANCH", i, command.String(session.Scenario().Columns))
+				}
+			} else if branch, err := strconv.Atoi(fields[1]); err != nil || !session.SwitchBranch(branch) {
+				fmt.Println("INVALID BRANCH")
+			}
+			continue
+		case fields[0] == "hint":
+			solver := NewSolver()
+			solver.Timeout = hintTimeout
+			fmt.Println("HINT:", solver.Solve(session.Sequence()).Hint())
+			continue
+		case fields[0] == "save":
+			if len(fields) != 2 {
+				fmt.Println("USAGE: save [FILE]")
+			} else if err := session.Save(fields[1]); err != nil {
+				fmt.Println(err)
+			}
+			continue
+		default:
+			for _, token := range fields {
+				cmd, err := session.Sequence().CommandFromString(token)
+				if err == nil {
+					err = session.Apply(cmd)
+				}
+				if err != nil {
+					fmt.Println("ACTION NOT ALLOWED:", err)
+					break
 				}
 			}
 		}
-		if startSequence.IsFound() {
-			break
+		session.Sequence().Fdraw(os.Stdout, renderer, renderOptions)
+	}
+}
+
+func exportMain(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	anchor := anchorFlags(flags)
+	animation := DefaultAnimationOptions()
+	format := flags.String("format", "svg", "Image format (svg, png or gif for an animation of the solution)")
+	cellSize := flags.Int("cell", animation.CellSize, "Size of each cell in pixels")
+	steps := flags.Bool("steps", false, "Write an image for every step of the solution (numbered)")
+	sheet := flags.Bool("sheet", false, "Write every step of the solution as a single contact sheet")
+	flags.IntVar(&animation.SlideFrames, "slide-frames", animation.SlideFrames, "Frames used to animate each slide (gif only)")
+	flags.DurationVar(&animation.StepDelay, "delay", animation.StepDelay, "Delay between animation frames (gif only)")
+	flags.DurationVar(&animation.Pause, "pause", animation.Pause, "Time to pause after each command (gif only)")
+	output := flags.String("o", "", "File to write (default maze.svg, maze.png or maze.gif)")
+	flags.Parse(args)
+
+	if flags.NArg() < 3 || (*format != "svg" && *format != "png" && *format != "gif") || (*steps && *sheet) ||
+		(*format == "gif" && (*steps || *sheet)) {
+		usage()
+	}
+	scenario, start := parseArgs(flags.Args()[:3], rules(), anchor)
+	solution := strings.Join(flags.Args()[3:], " ")
+
+	verification := Verify(start.maze, scenario.Turns, rules(), solution)
+	if verification.Step > 0 {
+		log.Fatal(verification)
+	}
+	frames := verification.Sequence.ImageFrames(ImageOptions{CellSize: *cellSize, ShowExit: true})
+	animation.CellSize = *cellSize
+
+	path := *output
+	if path == "" {
+		path = "maze." + *format
+	}
+	write := func(path string, frames ...*ImageFrame) {
+		f, err := os.Create(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		switch *format {
+		case "gif":
+			err = WriteGIF(f, verification.Sequence, animation)
+		case "png":
+			err = WritePNG(f, frames...)
+		default:
+			err = WriteSVG(f, frames...)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("WROTE", path)
+	}
+
+	if *steps {
+		ext := filepath.Ext(path)
+		for i, frame := range frames {
+			write(fmt.Sprintf("%s-%02d%s", strings.TrimSuffix(path, ext), i, ext), frame)
+		}
+	} else if *sheet {
+		write(path, frames...)
+	} else {
+		write(path, frames[len(frames)-1])
+	}
+}
+
+func solveMain(args []string) {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	render := renderFlags(flags)
+	format := flags.String("format", "text", "Output format (text, json or jsonl for one line per scenario)")
+	timeout := flags.Duration("timeout", 0, "Give up after this long (no limit if 0)")
+	patterns := flags.Bool("patterns", false, "Include the maze pattern after each step (json only)")
+	batch := flags.String("batch", "", "Solve every scenario in the file (PATTERN DIMENSIONS TURNS or a JSON scenario per line)")
+	backward := flags.Uint("backward", 0, "First search backwards from the exit up to this many turns (to skip budgets proven too few)")
+	certificate := flags.String("certificate", "", "File to write a certificate to if the search proves there's no solution (json)")
+	anchor := anchorFlags(flags)
+	flags.Parse(args)
+	renderer, renderOptions := render()
+
+	if (*format != "text" && *format != "json" && *format != "jsonl") || *backward > 254 {
+		usage()
+	}
+	scenarios := []*Scenario{}
+	if *batch != "" {
+		if flags.NArg() > 0 || *certificate != "" || flags.Lookup("anchors").Value.String() != "" {
+			usage()
+		}
+		var err error
+		if scenarios, err = readScenarios(*batch, rules().Name); err != nil {
+			log.Fatal(err)
+		}
+		if *format == "json" {
+			*format = "jsonl" // Batch runs are always written as JSON Lines
+		}
+	} else {
+		if flags.NArg() != 3 {
+			usage()
+		}
+		scenario, err := ParseScenario(flags.Arg(0), flags.Arg(1), flags.Arg(2), rules().Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			usage()
+		}
+		anchor(scenario)
+		scenarios = append(scenarios, scenario)
+	}
+
+	for _, scenario := range scenarios {
+		start, err := scenario.Sequence()
+		if err != nil {
+			log.Fatal(err)
+		}
+		solver := NewSolver()
+		solver.Timeout = *timeout
+		solver.Verbose = *format == "text"
+		var bound *BackwardResult
+		if *backward > 0 {
+			bound = BackwardSearch(start, uint8(*backward))
+			if *format == "text" {
+				fmt.Println("BACKWARD:", bound)
+			}
+			solver.MinTurns = uint8(bound.LowerBound())
+		}
+		result := solver.Solve(start)
+		if *certificate != "" {
+			writeCertificate(*certificate, scenario, result, bound, *format == "text")
+		}
+
+		switch *format {
+		case "json", "jsonl":
+			var dat []byte
+			if *format == "json" {
+				dat, err = json.MarshalIndent(NewSolveReport(scenario, result, *patterns), "", "  ")
+			} else {
+				dat, err = json.Marshal(NewSolveReport(scenario, result, *patterns))
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(dat))
+		default:
+			if result.Solution != nil {
+				result.Solution.FprintSummary(os.Stdout, renderer, renderOptions)
+				fmt.Printf("SOLVED IN %d TURN(S) (%s)\n", result.Turns, result.Elapsed)
+			} else {
+				fmt.Println(colorize("red", "NOT SOLVED: ", result.Hint()))
+			}
+		}
+	}
+}
+
+// writeCertificate writes the certificate of an unsolved search as JSON (or explains why there
+// isn't one)
+func writeCertificate(path string, scenario *Scenario, result *SolveResult, backward *BackwardResult, verbose bool) {
+	certificate, err := NewCertificate(scenario, result, backward)
+	if err != nil {
+		if result.Solution == nil {
+			fmt.Fprintf(os.Stderr, "No certificate written: %s\n", err)
+		}
+		return
+	}
+	dat, err := json.MarshalIndent(certificate, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(path, append(dat, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if verbose {
+		fmt.Println("CERTIFICATE:", certificate)
+		fmt.Println("WROTE", path)
+	}
+}
+
+// readScenarios reads a scenario from each (non-blank) line of a file, either as on the command
+// line (PATTERN DIMENSIONS TURNS, with the given rules) or as JSON
+func readScenarios(path string, rules string) ([]*Scenario, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scenarios := []*Scenario{}
+	for i, line := range strings.Split(string(dat), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		scenario := &Scenario{}
+		if strings.HasPrefix(line, "{") {
+			if err = json.Unmarshal([]byte(line), scenario); err == nil {
+				_, err = scenario.Sequence()
+			}
+		} else if fields := strings.Fields(line); len(fields) == 3 {
+			scenario, err = ParseScenario(fields[0], fields[1], fields[2], rules)
 		} else {
-			startSequence.Draw()
+			err = fmt.Errorf("Expected PATTERN DIMENSIONS TURNS")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, i+1, err)
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios, nil
+}
+
+func generateMain(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	render := renderFlags(flags)
+	density := flags.Float64("density", 0.5, "Chance of each wall being closed (0 to 1)")
+	oneSided := flags.Bool("one-sided", false, "Close each side of a wall independently (so the sides may disagree)")
+	seed := flags.Int64("seed", 0, "Random seed (default based on the time)")
+	attempts := flags.Int("attempts", 1000, "Give up after trying this many mazes (no limit if 0)")
+	timeout := flags.Duration("timeout", 10*time.Second, "Skip mazes which take longer than this to solve (no limit if 0)")
+	output := flags.String("o", "", "Scenario file to write the maze to")
+	flags.Parse(args)
+	renderer, renderOptions := render()
+
+	if flags.NArg() != 2 || *density < 0 || *density > 1 {
+		usage()
+	}
+	rows, columns, err := ParseDimensions(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		usage()
+	}
+	turns, err := strconv.ParseUint(flags.Arg(1), 10, 8)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		usage()
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	options := GeneratorOptions{
+		Rows:         rows,
+		Columns:      columns,
+		Turns:        uint8(turns),
+		Rules:        rules(),
+		WallDensity:  *density,
+		OneSided:     *oneSided,
+		Seed:         *seed,
+		Attempts:     *attempts,
+		SolveTimeout: *timeout,
+	}
+	generated, err := Generate(options)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scenario := generated.Scenario
+	generated.Result.Solution.FprintSummary(os.Stdout, renderer, renderOptions)
+	fmt.Printf("GENERATED %s %dx%d %d (seed %d, %d attempt(s))\n",
+		scenario.MazePattern, scenario.Rows, scenario.Columns, scenario.Turns, options.Seed, generated.Attempts)
+
+	if *output != "" {
+		dat, err := json.MarshalIndent(scenario, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = os.WriteFile(*output, append(dat, '\n'), 0644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("WROTE", *output)
+	}
+}
+
+func designMain(args []string) {
+	flags := flag.NewFlagSet("design", flag.ExitOnError)
+	rules := rulesFlag(flags)
+	maxTurns := flags.Uint("max-turns", 8, "Only keep mazes which can be solved in this many turns")
+	limit := flags.Duration("time", time.Minute, "How long to search for")
+	solveTimeout := flags.Duration("solve-timeout", 10*time.Second, "Reject mazes which take longer than this to solve (no limit if 0)")
+	workers := flags.Int("workers", runtime.NumCPU(), "Mazes solved at once")
+	temperature := flags.Float64("temperature", 1, "Initial temperature (0 for hill climbing)")
+	cooling := flags.Float64("cooling", 0.99, "Multiply the temperature by this after each round")
+	keep := flags.Int("keep", 10, "How many of the best mazes to list")
+	seed := flags.Int64("seed", 0, "Random seed (default based on the time)")
+	checkpoint := flags.String("checkpoint", "", "File to save the search to after every round (resumed if it exists)")
+	flags.Parse(args)
+
+	var designer *Designer
+	if _, err := os.Stat(*checkpoint); *checkpoint != "" && err == nil {
+		if flags.NArg() > 0 {
+			usage()
+		}
+		if designer, err = LoadDesigner(*checkpoint); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("RESUMED %s at round %d\n", *checkpoint, designer.Round)
+	} else {
+		if flags.NArg() < 1 || flags.NArg() > 2 || *maxTurns > 255 {
+			usage()
+		}
+		rows, columns, err := ParseDimensions(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			usage()
+		}
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		designer = NewDesigner(DesignOptions{
+			Rows:         rows,
+			Columns:      columns,
+			Rules:        rules(),
+			MaxTurns:     uint8(*maxTurns),
+			SolveTimeout: *solveTimeout,
+			Workers:      *workers,
+			Temperature:  *temperature,
+			Cooling:      *cooling,
+			Keep:         *keep,
+			Seed:         *seed,
+		})
+		if flags.NArg() == 2 {
+			if err = designer.Start(flags.Arg(1)); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	err := designer.Run(time.Now().Add(*limit), func(improved bool) {
+		if improved {
+			best := designer.Best[0]
+			fmt.Printf("ROUND %d: %s needs %d turn(s) (%d mazes solved)\n", designer.Round, best.Pattern, best.Turns, designer.Evaluated)
+		}
+		if *checkpoint != "" {
+			if err := designer.Save(*checkpoint); err != nil {
+				log.Fatal(err)
+			}
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	options := designer.Options
+	fmt.Printf("BEST after %d round(s) (seed %d):\n", designer.Round, options.Seed)
+	for _, best := range designer.Best {
+		fmt.Printf("%s %dx%d %d %q\n", best.Pattern, options.Rows, options.Columns, best.Turns, best.Solution)
+	}
+}
+
+// apiMain answers API calls read line by line from stdin (each a JSON request with the name of
+// its Call added) exactly as the WebAssembly build answers calls from JavaScript
+func apiMain(args []string) {
+	if len(args) > 0 {
+		usage()
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		call := struct{ Call string }{}
+		if err := json.Unmarshal(line, &call); err != nil {
+			dat, _ := json.Marshal(map[string]string{"Error": err.Error()})
+			fmt.Println(string(dat))
+			continue
 		}
+		fmt.Println(string(callAPI(call.Call, line)))
 	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serveMain(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "Address to listen on")
+	maxSolveTime := flags.Duration("max-solve-time", 5*time.Minute, "Longest time a solve may run")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		usage()
+	}
+
+	server := NewServer()
+	server.MaxSolveTime = *maxSolveTime
+	log.Fatal(server.Serve(*addr))
+}
 
-	fmt.Println()
-	fmt.Println()
-	startSequence.PrintSummary()
-	/*
-		ps := parallelsearch.New(
-			128,        // poolSize
-			int(turns), // searchDepth
-			8,          // searchLimit
-		)
-		ps.Start(startSequence)
+/////////////////////////////////////////////////////////////////////////////////////////////////////
 
-		found := ps.WaitForFound()
-		for _, s := range found {
-			sequence := s.(*Sequence)
-			sequence.PrintSummary()
-			break
+func main() {
+	runtime.GOMAXPROCS(16)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			replayMain(os.Args[2:])
+			return
+		case "verify":
+			verifyMain(os.Args[2:])
+			return
+		case "validate":
+			validateMain(os.Args[2:])
+			return
+		case "stats":
+			statsMain(os.Args[2:])
+			return
+		case "distances":
+			distancesMain(os.Args[2:])
+			return
+		case "export":
+			exportMain(os.Args[2:])
+			return
+		case "serve":
+			serveMain(os.Args[2:])
+			return
+		case "api":
+			apiMain(os.Args[2:])
+			return
+		case "solve":
+			solveMain(os.Args[2:])
+			return
+		case "generate":
+			generateMain(os.Args[2:])
+			return
+		case "design":
+			designMain(os.Args[2:])
+			return
 		}
-	*/
+	}
+
+	playMain(os.Args[1:])
 }
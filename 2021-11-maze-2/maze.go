@@ -2,38 +2,70 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"math/big"
 	"strings"
-
-	"github.com/ghetzel/go-stockutil/sliceutil"
 )
 
 type Maze struct {
-	cells   []byte
-	columns uint8
+	cells    []byte
+	columns  uint8
+	spare    byte // The tile pushed in by the next slide (if hasSpare)
+	hasSpare bool
+	anchors  *Anchors // Cells which never move (nil if none)
+	torus    bool     // Openings on opposite sides of the border lead to each other (see Rules.WrapMoves)
 }
 
 type Highlighter func(int, int) bool
 
-func NewMaze(mazePattern string, columns uint8) *Maze {
-	if len(mazePattern) > 255 {
-		log.Fatal("Maze pattern is too large (must be < 256 characters)")
-	} else if uint8(len(mazePattern))%columns != 0 {
-		log.Fatal("Maze pattern has mismatched row sizes")
+// ParseMaze reads a maze from its hex pattern (a digit per cell, row by row) or returns why the
+// pattern is invalid.  A pattern with one digit more than a whole number of rows ends with the
+// spare tile (for the labyrinth rules).
+func ParseMaze(mazePattern string, columns uint8) (*Maze, error) {
+	hasSpare := columns > 1 && len(mazePattern) > int(columns) && len(mazePattern)%int(columns) == 1
+	if len(mazePattern) == 0 {
+		return nil, fmt.Errorf("Maze pattern is empty")
+	} else if len(mazePattern) > 255 {
+		return nil, fmt.Errorf("Maze pattern is too large (must be < 256 characters)")
+	} else if columns == 0 || (uint8(len(mazePattern))%columns != 0 && !hasSpare) {
+		return nil, fmt.Errorf("Maze pattern has mismatched row sizes")
 	}
 
 	cells := make([]byte, len(mazePattern), len(mazePattern))
 	for i, c := range strings.ToLower(mazePattern) {
-		cells[i] = charToHex(c)
+		b, ok := charToHex(c)
+		if !ok {
+			return nil, fmt.Errorf("Invalid hex character: %q", c)
+		}
+		cells[i] = b
+	}
+	if hasSpare {
+		return &Maze{cells[:len(cells)-1], columns, cells[len(cells)-1], true, nil, false}, nil
 	}
-	return &Maze{cells, columns}
+	return &Maze{cells, columns, 0, false, nil, false}, nil
 }
 
 func (self *Maze) Copy() *Maze {
 	cells := make([]byte, self.TotalCells(), self.TotalCells())
 	copy(cells, self.cells)
-	return &Maze{cells, self.columns}
+	return &Maze{cells, self.columns, self.spare, self.hasSpare, self.anchors, self.torus}
+}
+
+// Pattern is the hex string describing the maze (as given to NewMaze), ending with the spare tile
+// if there is one
+func (self *Maze) Pattern() string {
+	var s strings.Builder
+	for _, b := range self.cells {
+		fmt.Fprintf(&s, "%x", b)
+	}
+	if self.hasSpare {
+		fmt.Fprintf(&s, "%x", self.spare)
+	}
+	return s.String()
+}
+
+// Spare is the tile the next slide pushes in (or false if slides wrap around instead)
+func (self *Maze) Spare() (byte, bool) {
+	return self.spare, self.hasSpare
 }
 
 func (self *Maze) Columns() uint8 {
@@ -48,24 +80,145 @@ func (self *Maze) TotalCells() uint8 {
 	return uint8(len(self.cells))
 }
 
-func (self *Maze) Slide(command Command) *Maze {
+// Slide moves a row/column (or a segment of it) along by a cell (or by the command's distance, a
+// cell at a time).  The cell pushed off one end wraps around to the other unless there is a spare
+// tile, in which case the spare is pushed in instead (rotated as the command says) and the cell
+// pushed off becomes the spare.  Anchored cells stay where they are (see Anchors).
+func (self *Maze) Slide(command Command) (*Maze, error) {
+	if command.distance > 1 {
+		step := command
+		step.distance = 0
+		maze := self
+		for i := uint8(0); i < command.distance; i++ {
+			var err error
+			if maze, err = maze.Slide(step); err != nil {
+				return nil, err
+			}
+		}
+		return maze, nil
+	}
+	if self.anchors != nil || command.end > 0 {
+		if err := self.checkSlid(command); err != nil {
+			return nil, err
+		}
+		return self.slideCells(command), nil
+	}
+	var maze *Maze
+	var err error
 	switch command.operation {
 	case SLIDE_RIGHT:
-		return self.SlideHorizontal(command.argument, true)
+		maze, err = self.SlideHorizontal(command.argument, true)
 	case SLIDE_LEFT:
-		return self.SlideHorizontal(command.argument, false)
+		maze, err = self.SlideHorizontal(command.argument, false)
 	case SLIDE_DOWN:
-		return self.SlideVertical(command.argument, true)
+		maze, err = self.SlideVertical(command.argument, true)
 	case SLIDE_UP:
-		return self.SlideVertical(command.argument, false)
+		maze, err = self.SlideVertical(command.argument, false)
 	default:
-		return self
+		return self, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	if self.hasSpare {
+		entrance := self.entrance(command)
+		maze.spare = maze.cells[entrance]
+		maze.cells[entrance] = rotateNibble(self.spare, command.rotation)
+	}
+	return maze, nil
 }
 
-func (self *Maze) SlideHorizontal(row uint8, right bool) *Maze {
-	if (right && row >= self.Rows()) || (!right && row == 0) {
-		log.Fatal("Invalid row: ", row)
+// Rotate turns the walls of the cell at the given location clockwise
+func (self *Maze) Rotate(location uint8, quarterTurns uint8) *Maze {
+	maze := self.Copy()
+	maze.cells[location] = rotateNibble(self.cells[location], quarterTurns)
+	return maze
+}
+
+// Change is the maze after a slide or rotation (a move leaves it unchanged)
+func (self *Maze) Change(command Command) (*Maze, error) {
+	if command.operation == ROTATE {
+		if command.argument >= self.TotalCells() {
+			return nil, fmt.Errorf("Invalid cell: %d", command.argument)
+		}
+		return self.Rotate(command.argument, command.rotation), nil
+	}
+	return self.Slide(command)
+}
+
+// Undo undoes the slide or rotation (which for a slide pushing in a spare tile means turning the
+// spare back after pushing it out)
+func (self *Maze) Undo(command Command) (*Maze, error) {
+	maze, err := self.Change(command.Inverse())
+	if err != nil {
+		return nil, err
+	}
+	if maze.hasSpare && command.operation != ROTATE {
+		maze.spare = rotateNibble(maze.spare, (4-command.rotation%4)%4)
+	}
+	return maze, nil
+}
+
+// entrance is where the cell pushed off the end of the slid row/column wraps around to (which is
+// where a spare tile is pushed in)
+func (self *Maze) entrance(command Command) uint8 {
+	if sliding := self.sliding(command); (self.anchors != nil || command.end > 0) && len(sliding) > 0 {
+		return sliding[0]
+	}
+	switch command.operation {
+	case SLIDE_RIGHT:
+		return command.argument * self.columns
+	case SLIDE_LEFT:
+		return command.argument*self.columns + self.columns - 1
+	case SLIDE_DOWN:
+		return command.argument
+	default:
+		return (self.Rows()-1)*self.columns + command.argument
+	}
+}
+
+// rotateNibble turns a cell's openings clockwise by the given quarter turns (N=8, E=4, S=2, W=1)
+func rotateNibble(b byte, quarterTurns uint8) byte {
+	for i := uint8(0); i < quarterTurns%4; i++ {
+		b = (b>>1 | b<<3) & 15
+	}
+	return b
+}
+
+// cellRotations are the rotations of the cell at the given location which change it (leaving out
+// any which look the same as one before)
+func (self *Maze) cellRotations(location uint8) []uint8 {
+	rotations := []uint8{}
+	seen := map[byte]bool{self.cells[location]: true}
+	for rotation := uint8(1); rotation < 4; rotation++ {
+		if cell := rotateNibble(self.cells[location], rotation); !seen[cell] {
+			seen[cell] = true
+			rotations = append(rotations, rotation)
+		}
+	}
+	return rotations
+}
+
+// spareRotations are the rotations of the spare tile worth pushing in (those which leave it
+// looking different), or just no rotation if there is no spare tile
+func (self *Maze) spareRotations() []uint8 {
+	rotations := []uint8{0}
+	if !self.hasSpare {
+		return rotations
+	}
+	seen := map[byte]bool{self.spare: true}
+	for rotation := uint8(1); rotation < 4; rotation++ {
+		if tile := rotateNibble(self.spare, rotation); !seen[tile] {
+			seen[tile] = true
+			rotations = append(rotations, rotation)
+		}
+	}
+	return rotations
+}
+
+func (self *Maze) SlideHorizontal(row uint8, right bool) (*Maze, error) {
+	if row >= self.Rows() {
+		return nil, fmt.Errorf("Invalid row: %d", row)
 	}
 	maze := self.Copy()
 
@@ -76,15 +229,16 @@ func (self *Maze) SlideHorizontal(row uint8, right bool) *Maze {
 		maze.cells[idx1] = self.cells[idx2-1]
 		copy(maze.cells[idx1+1:], self.cells[idx1:idx2-1])
 	} else {
-		copy(maze.cells[idx1:], self.cells[idx1+1:idx2-2])
+		copy(maze.cells[idx1:], self.cells[idx1+1:idx2])
+		maze.cells[idx2-1] = self.cells[idx1]
 	}
 
-	return maze
+	return maze, nil
 }
 
-func (self *Maze) SlideVertical(column uint8, down bool) *Maze {
+func (self *Maze) SlideVertical(column uint8, down bool) (*Maze, error) {
 	if column >= self.columns {
-		log.Fatal("Invalid column: ", column)
+		return nil, fmt.Errorf("Invalid column: %d", column)
 	}
 	maze := self.Copy()
 
@@ -101,9 +255,143 @@ func (self *Maze) SlideVertical(column uint8, down bool) *Maze {
 		maze.cells[(self.Rows()-1)*self.columns+column] = self.cells[column]
 	}
 
+	return maze, nil
+}
+
+// lineLength is the number of cells slid by the command: those in its segment or else the whole
+// row/column
+func (self *Maze) lineLength(command Command) uint8 {
+	if command.end > 0 {
+		return command.end - command.start
+	} else if command.operation == SLIDE_DOWN || command.operation == SLIDE_UP {
+		return self.Rows()
+	}
+	return self.columns
+}
+
+// line is every cell of the slid row/column (or just its segment) in the order they move along (so
+// each cell moves to the next one and the last wraps around to the first)
+func (self *Maze) line(command Command) []uint8 {
+	line := []uint8{}
+	switch command.operation {
+	case SLIDE_RIGHT, SLIDE_LEFT:
+		for column := uint8(0); column < self.columns; column++ {
+			if command.covers(column) {
+				line = append(line, command.argument*self.columns+column)
+			}
+		}
+	case SLIDE_DOWN, SLIDE_UP:
+		for row := uint8(0); row < self.Rows(); row++ {
+			if command.covers(row) {
+				line = append(line, row*self.columns+command.argument)
+			}
+		}
+	}
+	if command.operation == SLIDE_LEFT || command.operation == SLIDE_UP {
+		for i, j := 0, len(line)-1; i < j; i, j = i+1, j-1 {
+			line[i], line[j] = line[j], line[i]
+		}
+	}
+	return line
+}
+
+// sliding is the cells of the slid row/column (or segment) which move (in the order they move along, skipping
+// any anchors)
+func (self *Maze) sliding(command Command) []uint8 {
+	sliding := []uint8{}
+	for _, location := range self.line(command) {
+		if !self.Anchored(location) {
+			sliding = append(sliding, location)
+		}
+	}
+	return sliding
+}
+
+// checkSlid makes sure the row/column slid is in the maze (and so is all of its segment)
+func (self *Maze) checkSlid(command Command) error {
+	switch command.operation {
+	case SLIDE_RIGHT, SLIDE_LEFT:
+		if command.argument >= self.Rows() {
+			return fmt.Errorf("Invalid row: %d", command.argument)
+		}
+	case SLIDE_DOWN, SLIDE_UP:
+		if command.argument >= self.columns {
+			return fmt.Errorf("Invalid column: %d", command.argument)
+		}
+	}
+	if command.end > 0 {
+		whole := command
+		whole.start, whole.end = 0, 0
+		if command.end > self.lineLength(whole) || command.start >= command.end {
+			return fmt.Errorf("Invalid segment: [%d:%d]", command.start, command.end)
+		}
+	}
+	return nil
+}
+
+// slideCells slides the cells of the row/column (or segment) which aren't anchored, each moving to
+// the next cell which isn't anchored
+func (self *Maze) slideCells(command Command) *Maze {
+	maze := self.Copy()
+	sliding := self.sliding(command)
+	if self.Blocked(command) || len(sliding) == 0 {
+		return maze
+	}
+	for i, location := range sliding {
+		maze.cells[sliding[(i+1)%len(sliding)]] = self.cells[location]
+	}
+	if self.hasSpare {
+		maze.spare = self.cells[sliding[len(sliding)-1]]
+		maze.cells[sliding[0]] = rotateNibble(self.spare, command.rotation)
+	}
 	return maze
 }
 
+// SlideLocation determines where the cell at the given location ends up after the slide
+func (self *Maze) SlideLocation(command Command, location uint8) uint8 {
+	if command.distance > 1 {
+		step := command
+		step.distance = 0
+		for i := uint8(0); i < command.distance; i++ {
+			location = self.SlideLocation(step, location)
+		}
+		return location
+	}
+	if self.anchors != nil || command.end > 0 {
+		if self.Blocked(command) {
+			return location
+		}
+		sliding := self.sliding(command)
+		for i, l := range sliding {
+			if l == location {
+				return sliding[(i+1)%len(sliding)]
+			}
+		}
+		return location
+	}
+	row := location / self.columns
+	column := location % self.columns
+	switch command.operation {
+	case SLIDE_RIGHT:
+		if row == command.argument {
+			return row*self.columns + (column+1)%self.columns
+		}
+	case SLIDE_LEFT:
+		if row == command.argument {
+			return row*self.columns + (column+self.columns-1)%self.columns
+		}
+	case SLIDE_DOWN:
+		if column == command.argument {
+			return (row+1)%self.Rows()*self.columns + column
+		}
+	case SLIDE_UP:
+		if column == command.argument {
+			return (row+self.Rows()-1)%self.Rows()*self.columns + column
+		}
+	}
+	return location
+}
+
 func (self *Maze) AccessibleLocations(currentLocation uint8) <-chan uint8 {
 	accessible := make(chan uint8)
 	go func() {
@@ -121,109 +409,126 @@ func (self *Maze) accessibleLocationsFrom(location uint8, visited *big.Int, acce
 	visited.SetBit(visited, int(location), 1)
 	accessible <- location
 
+	for _, neighbour := range self.openNeighbours(location) {
+		self.accessibleLocationsFrom(neighbour, visited, accessible)
+	}
+}
+
+// openNeighbours are the locations the player can step to directly from the given location
+func (self *Maze) openNeighbours(location uint8) []uint8 {
+	neighbours := []uint8{}
 	// If can go north
-	if location > self.columns && self.cells[location]&8 > 0 && self.cells[location-self.columns]&2 > 0 {
-		self.accessibleLocationsFrom(location-self.columns, visited, accessible)
+	if location >= self.columns && self.cells[location]&8 > 0 && self.cells[location-self.columns]&2 > 0 {
+		neighbours = append(neighbours, location-self.columns)
 	}
 	// If can go east
 	if (location+1)%self.columns != 0 && self.cells[location]&4 > 0 && self.cells[location+1]&1 > 0 {
-		self.accessibleLocationsFrom(location+1, visited, accessible)
+		neighbours = append(neighbours, location+1)
 	}
 	// If can go south
 	if location+self.columns < self.TotalCells() && self.cells[location]&2 > 0 && self.cells[location+self.columns]&8 > 0 {
-		self.accessibleLocationsFrom(location+self.columns, visited, accessible)
+		neighbours = append(neighbours, location+self.columns)
 	}
 	// If can go west
 	if location%self.columns != 0 && self.cells[location]&1 > 0 && self.cells[location-1]&4 > 0 {
-		self.accessibleLocationsFrom(location-1, visited, accessible)
+		neighbours = append(neighbours, location-1)
+	}
+	// If can go off the edge and around to the other side
+	for _, side := range []byte{8, 4, 2, 1} {
+		if neighbour, ok := self.wrapNeighbour(location, side); ok {
+			neighbours = append(neighbours, neighbour)
+		}
 	}
+	return neighbours
 }
 
-func (self *Maze) Draw(currentLocation uint8, highlighter Highlighter) {
-	normalBlock := colorize("cyan", "██")
-	//highlightedBlock := colorize("magenta", "██")
-	highlightedBlock := colorize("magenta", "▓▓")
-	me := colorize("yellow", "¥ ")
-
-	var s1 strings.Builder
-	var s2 strings.Builder
-	var s3 strings.Builder
-
-	for i := uint8(0); i < self.columns; i++ {
-		fmt.Print("______")
+// wrapNeighbour is the cell on the opposite side of the maze which the player can step to through
+// the given side (N=8, E=4, S=2, W=1) of a cell on the border.  This is only possible in a torus and
+// only if both cells are open on the sides facing off the edge.
+func (self *Maze) wrapNeighbour(location uint8, side byte) (uint8, bool) {
+	if !self.torus {
+		return 0, false
 	}
-	fmt.Println("__")
-
-	for row, rowData := range sliceutil.Chunks(self.cells, int(self.columns)) {
-		s1.WriteRune('│')
-		s2.WriteRune('│')
-		s3.WriteRune('│')
+	rows, columns := self.Rows(), self.columns
+	row, column := location/columns, location%columns
+	var neighbour uint8
+	var opposite byte
+	switch {
+	case side == 8 && row == 0 && rows > 1:
+		neighbour, opposite = (rows-1)*columns+column, 2
+	case side == 4 && column == columns-1 && columns > 1:
+		neighbour, opposite = row*columns, 1
+	case side == 2 && row == rows-1 && rows > 1:
+		neighbour, opposite = column, 8
+	case side == 1 && column == 0 && columns > 1:
+		neighbour, opposite = row*columns+columns-1, 4
+	default:
+		return 0, false
+	}
+	return neighbour, self.cells[location]&side > 0 && self.cells[neighbour]&opposite > 0
+}
 
-		for column, cellData := range rowData {
-			block := normalBlock
-			if highlighter != nil && highlighter(row, column) {
-				block = highlightedBlock
-			}
+// wrapSide is the side of the from cell which the player steps off the edge through to arrive
+// at the to cell on the opposite side (if that's the way between them rather than a step across)
+func (self *Maze) wrapSide(from uint8, to uint8) (byte, bool) {
+	for _, neighbour := range self.Wrap(false).openNeighbours(from) {
+		if neighbour == to {
+			return 0, false // Stepped straight across
+		}
+	}
+	for _, side := range []byte{8, 4, 2, 1} {
+		if neighbour, ok := self.wrapNeighbour(from, side); ok && neighbour == to {
+			return side, true
+		}
+	}
+	return 0, false
+}
 
-			b := cellData.(byte)
-			s1.WriteString(block)
-			if b&8 == 0 {
-				s1.WriteString(block)
-			} else {
-				s1.WriteString("  ")
-			}
-			s1.WriteString(block)
+// Wrap is a copy of the maze which is (or isn't) a torus
+func (self *Maze) Wrap(torus bool) *Maze {
+	maze := self.Copy()
+	maze.torus = torus
+	return maze
+}
 
-			if b&1 == 0 {
-				s2.WriteString(block)
-			} else {
-				s2.WriteString("  ")
-			}
-			if uint8(row)*self.columns+uint8(column) == currentLocation {
-				s2.WriteString(me)
-			} else {
-				s2.WriteString("  ")
-			}
-			if b&4 == 0 {
-				s2.WriteString(block)
-			} else {
-				s2.WriteString("  ")
+// ShortestPath is the cells walked (one per step, including both ends) along the shortest route
+// between two locations or nil if the destination can't be reached
+func (self *Maze) ShortestPath(from uint8, to uint8) []uint8 {
+	previous := make([]int, self.TotalCells())
+	for i := range previous {
+		previous[i] = -1
+	}
+	previous[from] = int(from)
+
+	queue := []uint8{from}
+	for len(queue) > 0 {
+		location := queue[0]
+		queue = queue[1:]
+		if location == to {
+			path := []uint8{to}
+			for location != from {
+				location = uint8(previous[location])
+				path = append([]uint8{location}, path...)
 			}
-
-			s3.WriteString(block)
-			if b&2 == 0 {
-				s3.WriteString(block)
-			} else {
-				s3.WriteString("  ")
+			return path
+		}
+		for _, neighbour := range self.openNeighbours(location) {
+			if previous[neighbour] < 0 {
+				previous[neighbour] = int(location)
+				queue = append(queue, neighbour)
 			}
-			s3.WriteString(block)
 		}
-
-		s1.WriteRune('│')
-		s2.WriteRune('│')
-		s3.WriteRune('│')
-		fmt.Println(s1.String())
-		fmt.Println(s2.String())
-		fmt.Println(s3.String())
-		s1.Reset()
-		s2.Reset()
-		s3.Reset()
-	}
-
-	for i := uint8(0); i < self.columns; i++ {
-		fmt.Print("¯¯¯¯¯¯")
 	}
-	fmt.Println("¯¯")
+	return nil
 }
 
-func charToHex(c rune) byte {
+func charToHex(c rune) (byte, bool) {
 	pattern := int(c)
 	if pattern >= 48 && pattern <= 57 {
-		return byte(pattern - 48)
+		return byte(pattern - 48), true
 	} else if pattern >= 97 && pattern <= 102 {
-		return byte(pattern - 87)
+		return byte(pattern - 87), true
 	} else {
-		log.Fatal("Invalid hex character: ", c)
-		return 0
+		return 0, false
 	}
 }
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"testing"
+)
+
+// mustParseMaze parses a maze pattern which is known to be valid
+func mustParseMaze(pattern string, columns uint8) *Maze {
+	maze, err := ParseMaze(pattern, columns)
+	if err != nil {
+		panic(err)
+	}
+	return maze
+}
+
+// TestUndo checks that undoing each slide (or rotation) the rules allow restores the maze, and
+// that sliding back takes every cell (and so a carried player) back to where it was
+func TestUndo(t *testing.T) {
+	for _, rules := range allRules {
+		for _, skip := range []bool{false, true} {
+			for _, maze := range testMazes(rules, 3, 4, 5) {
+				if skip {
+					anchors, err := ParseAnchors(maze, []string{"(1,2)", "(3,0)"}, true)
+					if err != nil {
+						t.Fatal(err)
+					}
+					maze = maze.Anchor(anchors)
+				}
+				for _, change := range changeCommands(maze, rules) {
+					name := change.String(maze.Columns())
+					changed, err := maze.Change(change)
+					if err != nil {
+						t.Fatalf("%s %s: %s", rules, name, err)
+					}
+					if undone, err := changed.Undo(change); err != nil || undone.Pattern() != maze.Pattern() {
+						t.Errorf("%s %s (skip anchors %v): %s undone as %v (%v)", rules, maze.Pattern(), skip, name, undone, err)
+					}
+					if change.operation == ROTATE || maze.hasSpare {
+						continue
+					}
+					for location := uint8(0); location < maze.TotalCells(); location++ {
+						slid := maze.SlideLocation(change, location)
+						if changed.cells[slid] != maze.cells[location] {
+							t.Errorf("%s %s (skip anchors %v): %s moved %d to %d but not its cell", rules, maze.Pattern(), skip, name, location, slid)
+						} else if back := changed.SlideLocation(change.Inverse(), slid); back != location {
+							t.Errorf("%s %s (skip anchors %v): %s moved %d to %d but back to %d", rules, maze.Pattern(), skip, name, location, slid, back)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBlockedSlides(t *testing.T) {
+	maze := testMazes(NovemberRules, 1, 4, 5)[0]
+	anchors, err := ParseAnchors(maze, []string{"(1,2)"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maze = maze.Anchor(anchors)
+	tests := []struct {
+		command Command
+		blocked bool
+	}{
+		{Command{SLIDE_RIGHT, 1, 0, 0, 0, 0}, true},
+		{Command{SLIDE_LEFT, 0, 0, 0, 0, 0}, false},
+		{Command{SLIDE_DOWN, 2, 0, 0, 0, 0}, true},
+		{Command{SLIDE_UP, 3, 0, 0, 0, 0}, false},
+		{Command{SLIDE_RIGHT, 1, 0, 0, 3, 5}, false},
+		{Command{SLIDE_RIGHT, 1, 0, 0, 1, 3}, true},
+	}
+	for _, test := range tests {
+		if maze.Blocked(test.command) != test.blocked {
+			t.Errorf("%s: blocked should be %v", test.command.String(maze.Columns()), test.blocked)
+		}
+	}
+}
+
+// TestSlides checks that slides in every direction move the whole row or column (or just the
+// segment given) round, by one unless a distance is given, so that sliding back restores the maze
+func TestSlides(t *testing.T) {
+	maze := mustParseMaze("0123456789ab", 4) // 3 rows of 4 columns
+	tests := []struct {
+		command Command
+		undo    Command
+		want    string
+	}{
+		{Command{SLIDE_RIGHT, 0, 0, 0, 0, 0}, Command{SLIDE_LEFT, 0, 0, 0, 0, 0}, "3012456789ab"},
+		{Command{SLIDE_LEFT, 0, 0, 0, 0, 0}, Command{SLIDE_RIGHT, 0, 0, 0, 0, 0}, "1230456789ab"},
+		{Command{SLIDE_LEFT, 2, 0, 0, 0, 0}, Command{SLIDE_RIGHT, 2, 0, 0, 0, 0}, "012345679ab8"},
+		{Command{SLIDE_DOWN, 1, 0, 0, 0, 0}, Command{SLIDE_UP, 1, 0, 0, 0, 0}, "0923416785ab"},
+		{Command{SLIDE_UP, 3, 0, 0, 0, 0}, Command{SLIDE_DOWN, 3, 0, 0, 0, 0}, "0127456b89a3"},
+		{Command{SLIDE_RIGHT, 1, 0, 3, 0, 0}, Command{SLIDE_LEFT, 1, 0, 3, 0, 0}, "0123567489ab"},
+		{Command{SLIDE_DOWN, 0, 0, 2, 0, 0}, Command{SLIDE_UP, 0, 0, 2, 0, 0}, "4123856709ab"},
+		{Command{SLIDE_RIGHT, 0, 0, 0, 1, 4}, Command{SLIDE_LEFT, 0, 0, 0, 1, 4}, "0312456789ab"},
+		{Command{SLIDE_UP, 2, 0, 0, 0, 2}, Command{SLIDE_DOWN, 2, 0, 0, 0, 2}, "0163452789ab"},
+	}
+	for _, test := range tests {
+		name := test.command.String(maze.Columns())
+		slid, err := maze.Slide(test.command)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if slid.Pattern() != test.want {
+			t.Errorf("%s: slid to %s, expected %s", name, slid.Pattern(), test.want)
+		}
+		if undone, err := slid.Slide(test.undo); err != nil || undone.Pattern() != maze.Pattern() {
+			t.Errorf("%s: slid back to %v (%v)", name, undone, err)
+		}
+	}
+}
+
+// TestSlideErrors checks that slides (and rotations) outside the maze fail rather than changing it
+func TestSlideErrors(t *testing.T) {
+	maze := mustParseMaze("0123456789ab", 4)
+	tests := []struct {
+		command Command
+		error   string
+	}{
+		{Command{SLIDE_RIGHT, 3, 0, 0, 0, 0}, "Invalid row: 3"},
+		{Command{SLIDE_UP, 4, 0, 0, 0, 0}, "Invalid column: 4"},
+		{Command{SLIDE_LEFT, 3, 0, 2, 0, 0}, "Invalid row: 3"},
+		{Command{SLIDE_DOWN, 4, 0, 0, 0, 2}, "Invalid column: 4"},
+		{Command{SLIDE_RIGHT, 0, 0, 0, 2, 5}, "Invalid segment: [2:5]"},
+		{Command{ROTATE, 12, 1, 0, 0, 0}, "Invalid cell: 12"},
+	}
+	for _, test := range tests {
+		name := test.command.String(maze.Columns())
+		if changed, err := maze.Change(test.command); err == nil || err.Error() != test.error {
+			t.Errorf("%s: changed to %v (%v), expected %s", name, changed, err, test.error)
+		}
+		if _, err := NewSequence(maze, 3, ExpressRules).Apply(test.command); err == nil || err.Error() != test.error {
+			t.Errorf("%s: applied (%v), expected %s", name, err, test.error)
+		}
+	}
+	if maze.Pattern() != "0123456789ab" {
+		t.Errorf("Changed to %s", maze.Pattern())
+	}
+}
+
+// TestOpenNeighboursSymmetric checks that the player can always walk back the way they came (so
+// the cells they can walk to are the same as those they can walk from)
+func TestOpenNeighboursSymmetric(t *testing.T) {
+	for _, rules := range []*Rules{NovemberRules, TorusRules} {
+		for _, maze := range testMazes(rules, 10, 4, 5) {
+			maze = maze.Wrap(rules.WrapMoves)
+			for from := uint8(0); from < maze.TotalCells(); from++ {
+				for _, to := range maze.openNeighbours(from) {
+					back := false
+					for _, neighbour := range maze.openNeighbours(to) {
+						back = back || neighbour == from
+					}
+					if !back {
+						t.Errorf("%s %s: can walk from %d to %d but not back", rules, maze.Pattern(), from, to)
+					}
+				}
+			}
+		}
+	}
+	// Both cells in the first column are open between them
+	maze := mustParseMaze("2080", 2)
+	if path := maze.ShortestPath(2, 0); len(path) != 2 {
+		t.Errorf("No path north from the first cell of the second row: %v", path)
+	}
+}
+
+func TestWrapNeighbours(t *testing.T) {
+	// Two rows of three: the top left is open north and west, the bottom left south and the top
+	// right east (so the top left leads around to the bottom left and to the top right)
+	maze := mustParseMaze("904200", 3)
+	torus := maze.Wrap(true)
+	tests := []struct {
+		location uint8
+		side     byte
+		want     uint8
+		ok       bool
+	}{
+		{0, 8, 3, true},
+		{0, 1, 2, true},
+		{2, 4, 0, true},
+		{3, 2, 0, true},
+		{3, 1, 0, false}, // The bottom left is closed to the west
+		{1, 8, 0, false}, // Not open at all
+		{4, 8, 0, false}, // Not on the border
+	}
+	for _, test := range tests {
+		got, ok := torus.wrapNeighbour(test.location, test.side)
+		if ok != test.ok || (ok && got != test.want) {
+			t.Errorf("%d side %d: got %d (%v), expected %d (%v)", test.location, test.side, got, ok, test.want, test.ok)
+		}
+		if _, ok := maze.wrapNeighbour(test.location, test.side); ok {
+			t.Errorf("%d side %d: wraps around a maze which isn't a torus", test.location, test.side)
+		}
+	}
+}
@@ -23,12 +23,16 @@ type Searchable interface {
 // ParallelSearch implements a breadth-first search of a tree of searchable "nodes"
 // This is done in parallel using a FIFO worker pool.
 type ParallelSearch struct {
-	workerPool  *workerpool.WorkerPool
-	depthLimit  int
-	searchLimit int
-	waiters     []*sync.WaitGroup
-	searched    []*uint64
-	found       chan Searchable
+	workerPool    *workerpool.WorkerPool
+	depthLimit    int
+	searchLimit   int
+	waiters       []*sync.WaitGroup
+	searched      []*uint64
+	found         chan Searchable
+	stopped       int32
+	stop          chan struct{}
+	stopOnce      sync.Once
+	depthFinished func(depth int, searched uint64)
 }
 
 // New creates a new parallel search.  The poolSize determines the number of simultaneous
@@ -50,9 +54,43 @@ func New(poolSize int, depthLimit int, searchLimit int) *ParallelSearch {
 		ps.searched[depth] = &d
 	}
 	ps.found = make(chan Searchable, searchLimit)
+	ps.stop = make(chan struct{})
+	ps.depthFinished = func(depth int, searched uint64) {
+		fmt.Println("================ FINISHED DEPTH ", depth, " [", searched, "] ==================")
+	}
 	return ps
 }
 
+// OnDepthFinished replaces the announcement printed as each depth/layer is completed.  It must be
+// called before Start.
+func (self *ParallelSearch) OnDepthFinished(depthFinished func(depth int, searched uint64)) {
+	self.depthFinished = depthFinished
+}
+
+// Stop abandons the search.  Any "nodes" not yet searched are skipped so that WaitForFound
+// returns promptly with whatever has been found so far.
+func (self *ParallelSearch) Stop() {
+	self.stopOnce.Do(func() {
+		atomic.StoreInt32(&self.stopped, 1)
+		close(self.stop)
+	})
+}
+
+// Stopped determines if the search was abandoned (by Stop or by reaching the searchLimit) rather
+// than running to completion
+func (self *ParallelSearch) Stopped() bool {
+	return atomic.LoadInt32(&self.stopped) != 0
+}
+
+// Searched is the number of "nodes" searched at each depth so far
+func (self *ParallelSearch) Searched() []uint64 {
+	searched := make([]uint64, len(self.searched))
+	for depth := range self.searched {
+		searched[depth] = atomic.LoadUint64(self.searched[depth])
+	}
+	return searched
+}
+
 // Start will initiate a new search with the given starting "node" or "nodes".  It will
 // announce the completion of each depth/layer as it proceeds.  NOTE: This method should
 // only be called once to avoid duplicate depth announcement.
@@ -71,6 +109,7 @@ func (self *ParallelSearch) WaitForFound() []Searchable {
 	for searchable := range self.found {
 		found = append(found, searchable)
 		if len(found) >= self.searchLimit {
+			self.Stop()
 			break
 		}
 	}
@@ -92,9 +131,16 @@ func (self *ParallelSearch) asyncSearch(searchable Searchable, depth int) {
 }
 
 func (self *ParallelSearch) search(searchable Searchable, depth int) {
+	if self.Stopped() {
+		self.waiters[depth].Done()
+		return
+	}
 	atomic.AddUint64(self.searched[depth], 1)
 	if searchable.IsFound() {
-		self.found <- searchable
+		select {
+		case self.found <- searchable:
+		case <-self.stop:
+		}
 	} else if depth < self.depthLimit { // Don't go past depthLimit
 		searchable.Search(func(nextSearchable Searchable) {
 			self.asyncSearch(nextSearchable, depth+1)
@@ -107,10 +153,11 @@ func (self *ParallelSearch) search(searchable Searchable, depth int) {
 func (self *ParallelSearch) announceDepthCompletion() {
 	for depth, waiter := range self.waiters {
 		waiter.Wait()
-		if *self.searched[depth] > 0 {
-			fmt.Println("================ FINISHED DEPTH ", depth, " [", *self.searched[depth], "] ==================")
+		if searched := atomic.LoadUint64(self.searched[depth]); searched > 0 && !self.Stopped() {
+			self.depthFinished(depth, searched)
 		}
 	}
 	// If we've run out of searchables to consider, stop looking for more results
 	close(self.found)
+	self.workerPool.Stop()
 }
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RenderOptions control what is drawn (in addition to the maze and player) and how
+type RenderOptions struct {
+	Color       bool        // Write ANSI color codes
+	Coordinates bool        // Label the rows and columns
+	ShowExit    bool        // Mark the exit (bottom right) cell
+	Highlighter Highlighter // Cells to highlight (e.g. the row/column just slid)
+	Reachable   Highlighter // Cells to shade (e.g. where the player can walk to)
+	Heatmap     []int       // A number to show in each cell, colored by size (e.g. turns to the exit, -1 for none)
+}
+
+// Renderer draws a maze (with the player at the given location) as text
+type Renderer interface {
+	Render(w io.Writer, maze *Maze, location uint8, options RenderOptions) error
+}
+
+// BlockRenderer draws each cell as 3x3 blocks (each two characters wide) in which an opening is
+// left blank.  This is the style recorded in transcripts.
+type BlockRenderer struct{}
+
+// ASCIIRenderer draws the maze with +--+ corners and walls using only ASCII characters
+type ASCIIRenderer struct{}
+
+// CompactRenderer draws each cell as a single box-drawing glyph whose lines lead to the cell's
+// openings
+type CompactRenderer struct{}
+
+var renderers = map[string]Renderer{
+	"block":   BlockRenderer{},
+	"ascii":   ASCIIRenderer{},
+	"compact": CompactRenderer{},
+}
+
+func RendererByName(name string) (Renderer, error) {
+	if renderer, ok := renderers[strings.ToLower(name)]; ok {
+		return renderer, nil
+	}
+	return nil, fmt.Errorf("Unknown style: %s (must be block, ascii or compact)", name)
+}
+
+func (self RenderOptions) highlighted(maze *Maze, location uint8) bool {
+	row, column := int(location/maze.Columns()), int(location%maze.Columns())
+	return self.Highlighter != nil && self.Highlighter(row, column)
+}
+
+func (self RenderOptions) reachable(maze *Maze, location uint8) bool {
+	row, column := int(location/maze.Columns()), int(location%maze.Columns())
+	return self.Reachable != nil && self.Reachable(row, column)
+}
+
+func (self RenderOptions) isExit(maze *Maze, location uint8) bool {
+	return self.ShowExit && location == maze.TotalCells()-1
+}
+
+// heat is the heatmap's number for the cell at the given location (fitted to the given width and
+// colored from green for the smallest to red for the largest) or false if there's no heatmap
+func (self RenderOptions) heat(location uint8, width int) (string, bool) {
+	if self.Heatmap == nil {
+		return "", false
+	}
+	value := self.Heatmap[location]
+	label := fmt.Sprintf("%*d", width, value)
+	if value < 0 {
+		label = fmt.Sprintf("%*s", width, "-")
+	} else if len(label) > width {
+		label = strings.Repeat("+", width)
+	}
+	return colorizeIf(self.Color, self.heatColor(value), label), true
+}
+
+// heatColor is the color of the given heatmap value (relative to the largest)
+func (self RenderOptions) heatColor(value int) string {
+	hottest := 0
+	for _, v := range self.Heatmap {
+		if v > hottest {
+			hottest = v
+		}
+	}
+	switch {
+	case value < 0:
+		return "gray"
+	case 3*value < hottest:
+		return "green"
+	case 3*value < 2*hottest:
+		return "yellow"
+	default:
+		return "red"
+	}
+}
+
+// columnLabels labels each column (centered within the given cell width) after the given indent
+func columnLabels(columns uint8, width int, indent int) string {
+	var s strings.Builder
+	s.WriteString(strings.Repeat(" ", indent))
+	for column := uint8(0); column < columns; column++ {
+		label := fmt.Sprint(column)
+		if len(label) > width {
+			label = label[len(label)-width:]
+		}
+		left := (width - len(label)) / 2
+		s.WriteString(strings.Repeat(" ", left))
+		s.WriteString(label)
+		s.WriteString(strings.Repeat(" ", width-len(label)-left))
+	}
+	return strings.TrimRight(s.String(), " ")
+}
+
+// rowLabel labels the line of a row (or leaves blank space for the lines which aren't labelled)
+func rowLabel(options RenderOptions, row uint8, labelled bool) string {
+	if !options.Coordinates {
+		return ""
+	} else if labelled {
+		return fmt.Sprintf("%3d ", row)
+	}
+	return "    "
+}
+
+// borderOpen determines if the given side of a cell on the border is drawn open: any opening unless
+// the maze is a torus, in which case only openings leading around to the other side
+func borderOpen(maze *Maze, location uint8, side byte) bool {
+	if maze.torus {
+		_, ok := maze.wrapNeighbour(location, side)
+		return ok
+	}
+	return maze.cells[location]&side > 0
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// borderLine is the top (or bottom) edge of a block maze, left open above (or below) any cell the
+// player can walk off the edge from and around to the other side
+func borderLine(maze *Maze, edge string, side byte) string {
+	columns := maze.Columns()
+	first := uint8(0)
+	if side == 2 {
+		first = (maze.Rows() - 1) * columns
+	}
+	var s strings.Builder
+	s.WriteString(edge)
+	for location := first; location < first+columns; location++ {
+		if _, ok := maze.wrapNeighbour(location, side); ok {
+			s.WriteString(strings.Repeat(edge, 2) + "  " + strings.Repeat(edge, 2))
+		} else {
+			s.WriteString(strings.Repeat(edge, 6))
+		}
+	}
+	s.WriteString(edge + "\n")
+	return s.String()
+}
+
+// borderSide is the left (or right) edge of a block maze beside the middle of a cell, left open if
+// the player can walk off the edge there and around to the other side
+func borderSide(maze *Maze, location uint8, side byte) string {
+	if _, ok := maze.wrapNeighbour(location, side); ok {
+		return " "
+	}
+	return "│"
+}
+
+func (self BlockRenderer) Render(w io.Writer, maze *Maze, location uint8, options RenderOptions) error {
+	normalBlock := colorizeIf(options.Color, "cyan", "██")
+	highlightedBlock := colorizeIf(options.Color, "magenta", "▓▓")
+	anchoredBlock := colorizeIf(options.Color, "blue", "▒▒")
+	reachableBlock := colorizeIf(options.Color, "green", "▓▓")
+	me := colorizeIf(options.Color, "yellow", "¥ ")
+	exit := colorizeIf(options.Color, "red", "<>")
+	columns := maze.Columns()
+
+	var s strings.Builder
+	if options.Coordinates {
+		s.WriteString(columnLabels(columns, 6, 5))
+		s.WriteRune('\n')
+	}
+	s.WriteString(rowLabel(options, 0, false))
+	s.WriteString(borderLine(maze, "_", 8))
+
+	var s1, s2, s3 strings.Builder
+	for i := uint8(0); i < maze.TotalCells(); i++ {
+		row := i / columns
+		if i%columns == 0 {
+			s1.WriteString(rowLabel(options, row, false))
+			s2.WriteString(rowLabel(options, row, true))
+			s3.WriteString(rowLabel(options, row, false))
+			s1.WriteRune('│')
+			s2.WriteString(borderSide(maze, i, 1))
+			s3.WriteRune('│')
+		}
+
+		block := normalBlock
+		if maze.Anchored(i) {
+			block = anchoredBlock
+		} else if options.highlighted(maze, i) {
+			block = highlightedBlock
+		} else if options.reachable(maze, i) {
+			block = reachableBlock
+		}
+		wall := func(open bool) string {
+			if open {
+				return "  "
+			}
+			return block
+		}
+
+		b := maze.cells[i]
+		s1.WriteString(block + wall(b&8 > 0) + block)
+		s2.WriteString(wall(b&1 > 0))
+		if i == location {
+			s2.WriteString(me)
+		} else if heat, ok := options.heat(i, 2); ok {
+			s2.WriteString(heat)
+		} else if options.isExit(maze, i) {
+			s2.WriteString(exit)
+		} else {
+			s2.WriteString("  ")
+		}
+		s2.WriteString(wall(b&4 > 0))
+		s3.WriteString(block + wall(b&2 > 0) + block)
+
+		if (i+1)%columns == 0 {
+			s1.WriteString("│\n")
+			s2.WriteString(borderSide(maze, i, 4) + "\n")
+			s3.WriteString("│\n")
+			for _, line := range []*strings.Builder{&s1, &s2, &s3} {
+				s.WriteString(line.String())
+				line.Reset()
+			}
+		}
+	}
+
+	s.WriteString(rowLabel(options, 0, false))
+	s.WriteString(borderLine(maze, "¯", 2))
+
+	if spare, ok := maze.Spare(); ok {
+		wall := func(open bool) string {
+			if open {
+				return "  "
+			}
+			return normalBlock
+		}
+		s.WriteString("       " + normalBlock + wall(spare&8 > 0) + normalBlock + "\n")
+		s.WriteString("SPARE: " + wall(spare&1 > 0) + "  " + wall(spare&4 > 0) + "\n")
+		s.WriteString("       " + normalBlock + wall(spare&2 > 0) + normalBlock + "\n")
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (self ASCIIRenderer) Render(w io.Writer, maze *Maze, location uint8, options RenderOptions) error {
+	columns := maze.Columns()
+	rows := maze.Rows()
+	cell := func(row, column uint8) byte {
+		return maze.cells[row*columns+column]
+	}
+
+	var s strings.Builder
+	if options.Coordinates {
+		s.WriteString(columnLabels(columns, 3, 5))
+		s.WriteRune('\n')
+	}
+
+	// wallLine draws the walls above the given row (or below the last row)
+	wallLine := func(row uint8) {
+		s.WriteString(rowLabel(options, row, false))
+		for column := uint8(0); column < columns; column++ {
+			s.WriteRune('+')
+			var open bool
+			if row == 0 {
+				open = borderOpen(maze, row*columns+column, 8)
+			} else if row == rows {
+				open = borderOpen(maze, (row-1)*columns+column, 2)
+			} else {
+				open = cell(row, column)&8 > 0 && cell(row-1, column)&2 > 0
+			}
+			if open {
+				s.WriteString("  ")
+			} else {
+				s.WriteString("--")
+			}
+		}
+		s.WriteString("+\n")
+	}
+
+	for row := uint8(0); row < rows; row++ {
+		wallLine(row)
+		s.WriteString(rowLabel(options, row, true))
+		for column := uint8(0); column < columns; column++ {
+			var open bool
+			if column == 0 {
+				open = borderOpen(maze, row*columns, 1)
+			} else {
+				open = cell(row, column)&1 > 0 && cell(row, column-1)&4 > 0
+			}
+			if open {
+				s.WriteRune(' ')
+			} else {
+				s.WriteRune('|')
+			}
+
+			i := row*columns + column
+			switch {
+			case i == location:
+				s.WriteString(colorizeIf(options.Color, "yellow", "@@"))
+			case options.Heatmap != nil:
+				heat, _ := options.heat(i, 2)
+				s.WriteString(heat)
+			case options.isExit(maze, i):
+				s.WriteString(colorizeIf(options.Color, "red", "<>"))
+			case maze.Anchored(i):
+				s.WriteString(colorizeIf(options.Color, "blue", "##"))
+			case options.highlighted(maze, i):
+				s.WriteString(colorizeIf(options.Color, "magenta", "::"))
+			case options.reachable(maze, i):
+				s.WriteString(colorizeIf(options.Color, "green", ".."))
+			default:
+				s.WriteString("  ")
+			}
+		}
+		if borderOpen(maze, row*columns+columns-1, 4) {
+			s.WriteString(" \n")
+		} else {
+			s.WriteString("|\n")
+		}
+	}
+	wallLine(rows)
+
+	if spare, ok := maze.Spare(); ok {
+		side := func(open bool, closed string) string {
+			if open {
+				return strings.Repeat(" ", len(closed))
+			}
+			return closed
+		}
+		s.WriteString("       +" + side(spare&8 > 0, "--") + "+\n")
+		s.WriteString("SPARE: " + side(spare&1 > 0, "|") + "  " + side(spare&4 > 0, "|") + "\n")
+		s.WriteString("       +" + side(spare&2 > 0, "--") + "+\n")
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// compactGlyphs are indexed by a cell's nibble (N=8, E=4, S=2, W=1)
+var compactGlyphs = []rune{
+	'·', '╴', '╷', '┐', '╶', '─', '┌', '┬',
+	'╵', '┘', '│', '┤', '└', '┴', '├', '┼',
+}
+
+func (self CompactRenderer) Render(w io.Writer, maze *Maze, location uint8, options RenderOptions) error {
+	columns := maze.Columns()
+
+	var s strings.Builder
+	if options.Coordinates {
+		s.WriteString(columnLabels(columns, 1, 4))
+		s.WriteRune('\n')
+	}
+	for i := uint8(0); i < maze.TotalCells(); i++ {
+		if i%columns == 0 {
+			s.WriteString(rowLabel(options, i/columns, true))
+		}
+
+		glyph := string(compactGlyphs[maze.cells[i]&15])
+		switch {
+		case i == location && options.Color:
+			s.WriteString(colorizeIf(true, "yellow", glyph))
+		case i == location:
+			s.WriteRune('@')
+		case options.Heatmap != nil && options.Color:
+			s.WriteString(colorizeIf(true, options.heatColor(options.Heatmap[i]), glyph))
+		case options.Heatmap != nil:
+			heat, _ := options.heat(i, 1) // Without color the glyph is replaced by the number
+			s.WriteString(heat)
+		case maze.Anchored(i) && options.Color:
+			s.WriteString(colorizeIf(true, "blue", glyph))
+		case maze.Anchored(i):
+			s.WriteRune('#') // Without color the glyph is replaced by a mark
+		case options.highlighted(maze, i):
+			s.WriteString(colorizeIf(options.Color, "magenta", glyph))
+		case options.reachable(maze, i):
+			s.WriteString(colorizeIf(options.Color, "green", glyph))
+		case options.isExit(maze, i):
+			s.WriteString(colorizeIf(options.Color, "red", glyph))
+		default:
+			s.WriteString(colorizeIf(options.Color, "cyan", glyph))
+		}
+
+		if (i+1)%columns == 0 {
+			s.WriteRune('\n')
+		}
+	}
+
+	if spare, ok := maze.Spare(); ok {
+		s.WriteString("SPARE: " + colorizeIf(options.Color, "cyan", string(compactGlyphs[spare&15])) + "\n")
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
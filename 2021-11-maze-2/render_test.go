@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderString renders the maze with the named style
+func renderString(t *testing.T, style string, maze *Maze, location uint8, options RenderOptions) string {
+	renderer, err := RendererByName(style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s strings.Builder
+	if err := renderer.Render(&s, maze, location, options); err != nil {
+		t.Fatal(err)
+	}
+	return s.String()
+}
+
+func TestRenderers(t *testing.T) {
+	// A path from the top left round to the bottom left with the player in the middle of the top
+	maze := mustParseMaze("653c59", 3)
+	secondRow := func(row, column int) bool { return row == 1 }
+	tests := []struct {
+		style   string
+		options RenderOptions
+		want    string
+	}{
+		{"ascii", RenderOptions{}, `
++--+--+--+
+|   @@   |
++  +--+  +
+|        |
++--+--+--+
+`},
+		{"ascii", RenderOptions{ShowExit: true, Highlighter: secondRow}, `
++--+--+--+
+|   @@   |
++  +--+  +
+|:: :: <>|
++--+--+--+
+`},
+		{"ascii", RenderOptions{Coordinates: true, Reachable: secondRow}, `
+      0  1  2
+    +--+--+--+
+  0 |   @@   |
+    +  +--+  +
+  1 |.. .. ..|
+    +--+--+--+
+`},
+		{"compact", RenderOptions{}, `
+┌@┐
+└─┘
+`},
+		{"compact", RenderOptions{Coordinates: true}, `
+    012
+  0 ┌@┐
+  1 └─┘
+`},
+		{"ASCII", RenderOptions{}, `
++--+--+--+
+|   @@   |
++  +--+  +
+|        |
++--+--+--+
+`},
+	}
+	for _, test := range tests {
+		got := renderString(t, test.style, maze, 1, test.options)
+		if want := test.want[1:]; got != want {
+			t.Errorf("%s %+v: rendered as\n%s\nexpected\n%s", test.style, test.options, got, want)
+		}
+	}
+
+	if _, err := RendererByName("fancy"); err == nil {
+		t.Error("Unknown style accepted")
+	}
+}
+
+// TestRenderColor checks that drawing in color only adds color codes
+func TestRenderColor(t *testing.T) {
+	maze := mustParseMaze("653c59", 3)
+	for _, style := range []string{"block", "ascii"} {
+		plain := renderString(t, style, maze, 1, RenderOptions{ShowExit: true})
+		colored := renderString(t, style, maze, 1, RenderOptions{ShowExit: true, Color: true})
+		if colored == plain {
+			t.Errorf("%s: not colored", style)
+		} else if StripColorCodes(colored) != plain {
+			t.Errorf("%s: colored as\n%s", style, colored)
+		}
+	}
+}
+
+// TestBlockRendererTranscript checks that the block style (as recorded in transcripts) is read
+// back as the same maze with the player in the same place
+func TestBlockRendererTranscript(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		columns  uint8
+		location uint8
+	}{
+		{"653c59", 3, 1},
+		{"653c59", 3, 5},
+		{"43020c596163c1c9", 4, 6},
+		{"fedcba9876543210", 2, 0},
+	}
+	for _, test := range tests {
+		maze := mustParseMaze(test.pattern, test.columns)
+		text := renderString(t, "block", maze, test.location, RenderOptions{Color: true, ShowExit: true})
+		transcript, err := ReadTranscript(strings.NewReader(text))
+		if err != nil {
+			t.Errorf("%s: %s", test.pattern, err)
+			continue
+		}
+		frame := transcript.Frames[0]
+		if frame.Maze.Pattern() != test.pattern || frame.Maze.Columns() != test.columns || frame.Location != test.location {
+			t.Errorf("%s: read back as %s (%d columns) with the player at %d", test.pattern, frame.Maze.Pattern(), frame.Maze.Columns(), frame.Location)
+		}
+	}
+}
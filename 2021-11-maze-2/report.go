@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+)
+
+// SolveReport is the outcome of solving a scenario in a form which can be written as JSON
+type SolveReport struct {
+	Scenario       Scenario
+	Rules          *Rules
+	Found          bool
+	Status         string // solved, unsolvable, timed out or cancelled
+	TurnsUsed      uint8  // 0 if not found
+	Commands       []SolveReportStep
+	Solution       string   // The commands in the usual notation (e.g. "R5 D3 (5,6) D6")
+	NodesPerDepth  []uint64 // Sequences searched at each depth by the last search run
+	Elapsed        string
+	ElapsedSeconds float64
+}
+
+// SolveReportStep is a command of the solution and where it leaves the player
+type SolveReportStep struct {
+	apiCommand
+	Location uint8  // Where the player is after the command
+	Pattern  string `json:",omitempty"` // The maze after the command (if asked for)
+}
+
+// NewSolveReport describes the result of solving the scenario, optionally including the maze
+// pattern after each step
+func NewSolveReport(scenario *Scenario, result *SolveResult, withPatterns bool) *SolveReport {
+	solution := newAPISolution(result)
+	report := &SolveReport{
+		Scenario:       *scenario,
+		Rules:          result.Start.rules,
+		Found:          result.Solution != nil,
+		Status:         solution.Status,
+		Commands:       []SolveReportStep{},
+		Solution:       strings.Join(solution.Solution, " "),
+		NodesPerDepth:  result.Searched,
+		Elapsed:        solution.Elapsed,
+		ElapsedSeconds: result.Elapsed.Seconds(),
+	}
+	if report.NodesPerDepth == nil {
+		report.NodesPerDepth = []uint64{}
+	}
+	if !report.Found {
+		return report
+	}
+
+	report.TurnsUsed = result.Turns
+	for s := result.Solution; s != result.Start; s = s.prev {
+		step := SolveReportStep{apiCommand: newAPICommand(s.prev, s.command), Location: s.location}
+		if withPatterns {
+			step.Pattern = s.maze.Pattern()
+		}
+		report.Commands = append([]SolveReportStep{step}, report.Commands...)
+	}
+	return report
+}
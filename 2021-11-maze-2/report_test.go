@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// jsonKeys are the keys of a JSON object (sorted)
+func jsonKeys(object map[string]interface{}) string {
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
+
+func TestSolveReport(t *testing.T) {
+	tests := []struct {
+		turns    string
+		patterns bool
+		status   string
+		solution string
+		steps    string // Keys of each step
+	}{
+		{"3", false, "solved", "(1,3) U2 (3,3)", "Argument Command Location Operation TurnCost"},
+		{"3", true, "solved", "(1,3) U2 (3,3)", "Argument Command Location Operation Pattern TurnCost"},
+		{"2", false, "unsolvable", "", ""},
+	}
+	for _, test := range tests {
+		scenario, err := ParseScenario("43020c596163c1c9", "4x4", test.turns, "november")
+		if err != nil {
+			t.Fatal(err)
+		}
+		start, _ := scenario.Sequence()
+		result := NewSolver().Solve(start)
+		dat, err := json.Marshal(NewSolveReport(scenario, result, test.patterns))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report := map[string]interface{}{}
+		if err := json.Unmarshal(dat, &report); err != nil {
+			t.Fatal(err)
+		}
+		if keys := jsonKeys(report); keys != "Commands Elapsed ElapsedSeconds Found NodesPerDepth Rules Scenario Solution Status TurnsUsed" {
+			t.Errorf("%s: report has %s", test.turns, keys)
+		}
+		if keys := jsonKeys(report["Scenario"].(map[string]interface{})); keys != "Columns MazePattern Rows Rules Turns" {
+			t.Errorf("%s: scenario has %s", test.turns, keys)
+		}
+		if report["Status"] != test.status || report["Solution"] != test.solution || report["Found"] != (test.solution != "") {
+			t.Errorf("%s: unexpected report %s", test.turns, dat)
+		}
+		if nodes, ok := report["NodesPerDepth"].([]interface{}); !ok || len(nodes) == 0 {
+			t.Errorf("%s: nodes per depth %v", test.turns, report["NodesPerDepth"])
+		}
+
+		commands, ok := report["Commands"].([]interface{})
+		if !ok || len(commands) != len(strings.Fields(test.solution)) {
+			t.Errorf("%s: commands %v", test.turns, report["Commands"])
+			continue
+		}
+		for i, command := range commands {
+			step := command.(map[string]interface{})
+			if keys := jsonKeys(step); keys != test.steps {
+				t.Errorf("%s: step %d has %s", test.turns, i+1, keys)
+			}
+			if step["Command"] != strings.Fields(test.solution)[i] {
+				t.Errorf("%s: step %d is %v", test.turns, i+1, step["Command"])
+			}
+		}
+	}
+}
+
+func TestParseScenario(t *testing.T) {
+	tests := []struct {
+		pattern, dimensions, turns, rules string
+		error                             bool
+	}{
+		{"43020c596163c1c9", "4x4", "3", "", false},
+		{"43020C596163C1C9", "2x8", "3", "october", false},
+		{"43020c596163c1c9", "4", "3", "", true},
+		{"43020c596163c1c9", "4x5", "3", "", true},
+		{"43020c596163c1c9", "4x4", "256", "", true},
+		{"43020c596163c1c9", "4x4", "3", "december", true},
+	}
+	for _, test := range tests {
+		scenario, err := ParseScenario(test.pattern, test.dimensions, test.turns, test.rules)
+		if (err != nil) != test.error {
+			t.Errorf("%s %s %s %s: error %v", test.pattern, test.dimensions, test.turns, test.rules, err)
+		} else if err == nil && scenario.MazePattern != strings.ToLower(test.pattern) {
+			t.Errorf("%s: pattern read as %s", test.pattern, scenario.MazePattern)
+		}
+	}
+}
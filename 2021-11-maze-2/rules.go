@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules describe which variant of the sliding maze puzzle is being played
+type Rules struct {
+	Name           string
+	ReverseSlides  bool // Rows may also slide left and columns may also slide up
+	CarryPlayer    bool // The player is carried along when their row/column is slid
+	LockPlayer     bool // The row/column the player is in can not be slid
+	PairedTurns    bool // A turn is a move optionally followed by a slide (instead of any one command)
+	SpareTile      bool // Slides push a spare tile in (turned as the command says) and the cell pushed out becomes the spare
+	RotateTiles    bool // A cell's walls may be turned by a quarter, half or three quarter turn
+	LockPlayerTile bool // The cell the player is in can not be turned
+	LongSlides     bool // A slide may move its row/column along by any number of cells (in one turn)
+	SegmentSlides  bool // A slide may move just a segment of its row/column around (the rest stays put)
+	WrapMoves      bool // The player can walk off the edge through an opening and back on at the opposite side
+}
+
+// OctoberRules are the rules of the October 2021 challenge: rows slide right and columns slide
+// down, carrying the player with them, and each turn is a move followed by a slide.
+var OctoberRules = &Rules{
+	Name:        "october",
+	CarryPlayer: true,
+	PairedTurns: true,
+}
+
+// NovemberRules are the rules of the November 2021 challenge: rows and columns slide in either
+// direction, but never the ones the player is in, and every command is a turn.
+var NovemberRules = &Rules{
+	Name:          "november",
+	ReverseSlides: true,
+	LockPlayer:    true,
+}
+
+// LabyrinthRules are the November rules with the spare tile of the Labyrinth board game: each
+// slide pushes the spare in (turned any way) and the cell pushed out becomes the spare.  Unlike the
+// board game the player's row/column can't be pushed, since any maze could be solved by pushing the
+// player off one end and around into the exit.
+var LabyrinthRules = &Rules{
+	Name:          "labyrinth",
+	ReverseSlides: true,
+	LockPlayer:    true,
+	SpareTile:     true,
+}
+
+// RotatingRules are the November rules where the walls of any cell but the player's may also be
+// turned (taking a turn like any other command)
+var RotatingRules = &Rules{
+	Name:           "rotating",
+	ReverseSlides:  true,
+	LockPlayer:     true,
+	RotateTiles:    true,
+	LockPlayerTile: true,
+}
+
+// ExpressRules are the October rules where a row/column may be slid along by any number of cells
+// in one turn (e.g. R4x5 rather than five R4s)
+var ExpressRules = &Rules{
+	Name:        "express",
+	CarryPlayer: true,
+	PairedTurns: true,
+	LongSlides:  true,
+}
+
+// SegmentRules are the November rules where a slide may move just part of a row/column around (e.g.
+// R2[3:7] slides the cells in columns 3 to 6 of row 2), never the part the player is in
+var SegmentRules = &Rules{
+	Name:          "segments",
+	ReverseSlides: true,
+	LockPlayer:    true,
+	SegmentSlides: true,
+}
+
+// TorusRules are the November rules on a maze which wraps around for walking as well as sliding:
+// an opening on the border leads to the opening (if there is one) on the opposite side
+var TorusRules = &Rules{
+	Name:          "torus",
+	ReverseSlides: true,
+	LockPlayer:    true,
+	WrapMoves:     true,
+}
+
+var allRules = []*Rules{OctoberRules, NovemberRules, LabyrinthRules, RotatingRules, ExpressRules, SegmentRules, TorusRules}
+
+func RulesByName(name string) (*Rules, error) {
+	for _, rules := range allRules {
+		if strings.EqualFold(rules.Name, name) {
+			return rules, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown rules: %s", name)
+}
+
+// CheckMaze returns why the maze can't be played by these rules (a spare tile is needed by the rules
+// which push one in and by no others)
+func (self *Rules) CheckMaze(maze *Maze) error {
+	if _, hasSpare := maze.Spare(); self.SpareTile && !hasSpare {
+		return fmt.Errorf("The %s rules need a spare tile (one more digit at the end of the pattern)", self.Name)
+	} else if !self.SpareTile && hasSpare {
+		return fmt.Errorf("The %s rules have no spare tile (the pattern has one digit too many)", self.Name)
+	}
+	return nil
+}
+
+func (self *Rules) String() string {
+	return self.Name
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Scenario is a maze to be solved within the given number of turns
+type Scenario struct {
+	Turns       uint8
+	Columns     uint8
+	Rows        uint8
+	MazePattern string
+	Rules       string
+	Anchors     []string `json:",omitempty"` // Cells which never move, e.g. "(1,1)"
+	SkipAnchors bool     `json:",omitempty"` // Slides pass over the anchors (instead of not being allowed through them)
+}
+
+// Sequence is the start of the scenario (or the reason the scenario is invalid)
+func (self *Scenario) Sequence() (*Sequence, error) {
+	maze, err := ParseMaze(self.MazePattern, self.Columns)
+	if err != nil || maze.Rows() != self.Rows {
+		return nil, fmt.Errorf("Maze pattern is not of size %dx%d", self.Rows, self.Columns)
+	}
+	rules := NovemberRules
+	if self.Rules != "" {
+		if rules, err = RulesByName(self.Rules); err != nil {
+			return nil, err
+		}
+	}
+	if err = rules.CheckMaze(maze); err != nil {
+		return nil, err
+	}
+	if len(self.Anchors) > 0 {
+		anchors, err := ParseAnchors(maze, self.Anchors, self.SkipAnchors)
+		if err != nil {
+			return nil, err
+		}
+		maze = maze.Anchor(anchors)
+	}
+	return NewSequence(maze, self.Turns, rules), nil
+}
+
+// ParseScenario reads a scenario as given on the command line: the maze pattern, its dimensions
+// (e.g. 4x5 for 4 rows of 5 columns), the number of turns and the name of the rules (if any)
+func ParseScenario(pattern string, dimensions string, turns string, rules string) (*Scenario, error) {
+	rows, columns, err := ParseDimensions(dimensions)
+	if err != nil {
+		return nil, err
+	}
+	t, err := strconv.ParseUint(turns, 10, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := &Scenario{Turns: uint8(t), Columns: columns, Rows: rows, MazePattern: strings.ToLower(pattern), Rules: rules}
+	if _, err = scenario.Sequence(); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+// ParseDimensions reads the size of a maze as ROWSxCOLUMNS (e.g. 4x5)
+func ParseDimensions(dimensions string) (uint8, uint8, error) {
+	parts := strings.SplitN(dimensions, "x", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("Dimensions must be two digits, e.g. 4x5")
+	}
+	rows, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	columns, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint8(rows), uint8(columns), nil
+}
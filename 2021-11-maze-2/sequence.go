@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"strconv"
 	"strings"
 
@@ -15,75 +15,189 @@ const (
 	SLIDE_LEFT        = 2
 	SLIDE_DOWN        = 3
 	SLIDE_UP          = 4
+	ROTATE            = 5 // Turn a cell's walls clockwise
 )
 
 type Command struct {
 	operation uint8
 	argument  uint8
+	rotation  uint8 // Quarter turns clockwise of the cell rotated (or the spare tile pushed in by a slide)
+	distance  uint8 // Cells slid along (if more than one)
+	start     uint8 // Only the segment of the row/column from start up to (not including) end is slid
+	end       uint8 // (or the whole row/column if 0)
+}
+
+// covers determines if the cell at the given index along the row/column is in the slid segment
+func (self Command) covers(index uint8) bool {
+	return self.end == 0 || (self.start <= index && index < self.end)
 }
 
 func (self Command) String(columns uint8) string {
+	rotation := ""
+	if self.end > 0 {
+		rotation = fmt.Sprint("[", self.start, ":", self.end, "]")
+	}
+	if self.distance > 1 {
+		rotation += fmt.Sprint("x", self.distance)
+	}
+	if self.rotation > 0 {
+		rotation += fmt.Sprint("+", self.rotation)
+	}
 	switch self.operation {
 	case MOVE:
 		row := self.argument / columns
 		column := self.argument % columns
 		return fmt.Sprint("(", row, ",", column, ")")
 	case SLIDE_RIGHT:
-		return fmt.Sprint("R", self.argument)
+		return fmt.Sprint("R", self.argument, rotation)
 	case SLIDE_LEFT:
-		return fmt.Sprint("L", self.argument)
+		return fmt.Sprint("L", self.argument, rotation)
 	case SLIDE_DOWN:
-		return fmt.Sprint("D", self.argument)
+		return fmt.Sprint("D", self.argument, rotation)
 	case SLIDE_UP:
-		return fmt.Sprint("U", self.argument)
+		return fmt.Sprint("U", self.argument, rotation)
+	case ROTATE:
+		row := self.argument / columns
+		column := self.argument % columns
+		return fmt.Sprint("T(", row, ",", column, ")+", self.rotation)
 	default:
 		return ""
 	}
 }
 
-func ParseCommand(maze *Maze, command string) Command {
+// Inverse is the slide which undoes this one, unless it turned a spare tile (see Maze.Undo).  A
+// move can't be undone without knowing where the player came from so is returned unchanged.
+func (self Command) Inverse() Command {
+	switch self.operation {
+	case SLIDE_RIGHT:
+		return Command{SLIDE_LEFT, self.argument, 0, self.distance, self.start, self.end}
+	case SLIDE_LEFT:
+		return Command{SLIDE_RIGHT, self.argument, 0, self.distance, self.start, self.end}
+	case SLIDE_DOWN:
+		return Command{SLIDE_UP, self.argument, 0, self.distance, self.start, self.end}
+	case SLIDE_UP:
+		return Command{SLIDE_DOWN, self.argument, 0, self.distance, self.start, self.end}
+	case ROTATE:
+		return Command{ROTATE, self.argument, (4 - self.rotation%4) % 4, 0, 0, 0}
+	default:
+		return self
+	}
+}
+
+// ParseCommand reads a command in the usual notation: (ROW,COLUMN) to move, R/L ROW to slide a row
+// right/left, D (or C)/U COLUMN to slide a column down/up and T(ROW,COLUMN)+N to turn a cell's walls
+// N quarter turns clockwise (one if +N is left out).  A slide may be followed by [START:END] to slide
+// only the cells from START up to (not including) END around (e.g. R2[3:7]) and then by xK to slide
+// K cells along at once (e.g. R4x3).  With a spare tile a slide may end with +N to turn the spare N quarter
+// turns clockwise before pushing it in (e.g. R2+1).
+func ParseCommand(maze *Maze, command string) (Command, error) {
+	if command == "" {
+		return Command{}, fmt.Errorf("Empty command")
+	}
+	if strings.HasPrefix(strings.ToUpper(command), "T(") {
+		cell, rotation := command[1:], 1
+		if i := strings.LastIndex(cell, "+"); i > 0 {
+			var err error
+			if rotation, err = strconv.Atoi(cell[i+1:]); err != nil || rotation < 1 || rotation > 3 {
+				return Command{}, fmt.Errorf("Invalid rotation (must be +1 to +3): %s", command)
+			}
+			cell = cell[:i]
+		}
+		move, err := ParseCommand(maze, cell)
+		if err != nil || move.operation != MOVE {
+			return Command{}, fmt.Errorf("Invalid rotation: %s", command)
+		}
+		return Command{ROTATE, move.argument, uint8(rotation), 0, 0, 0}, nil
+	}
+	if i := strings.Index(command, "+"); i > 0 && command[0] != '(' {
+		rotation, err := strconv.Atoi(command[i+1:])
+		if err != nil || rotation < 0 || rotation > 3 {
+			return Command{}, fmt.Errorf("Invalid rotation (must be +0 to +3): %s", command)
+		} else if !maze.hasSpare {
+			return Command{}, fmt.Errorf("Only a spare tile can be rotated: %s", command)
+		}
+		cmd, err := ParseCommand(maze, command[:i])
+		cmd.rotation = uint8(rotation)
+		return cmd, err
+	}
+	if i := strings.IndexAny(command, "xX"); i > 0 && command[0] != '(' {
+		cmd, err := ParseCommand(maze, command[:i])
+		if err != nil {
+			return cmd, err
+		}
+		length := maze.lineLength(cmd)
+		distance, err := strconv.Atoi(command[i+1:])
+		if err != nil || distance < 1 || distance >= int(length) {
+			return Command{}, fmt.Errorf("Invalid distance (must be x1 to x%d): %s", length-1, command)
+		}
+		if distance > 1 {
+			cmd.distance = uint8(distance)
+		}
+		return cmd, nil
+	}
+	if i := strings.Index(command, "["); i > 0 && command[0] != '(' {
+		cmd, err := ParseCommand(maze, command[:i])
+		if err != nil {
+			return cmd, err
+		}
+		length := maze.lineLength(cmd)
+		var start, end int
+		if n, _ := fmt.Sscanf(command[i:], "[%d:%d]", &start, &end); n != 2 || !strings.HasSuffix(command, "]") ||
+			start < 0 || end > int(length) || end-start < 2 {
+			return Command{}, fmt.Errorf("Invalid segment (must be at least 2 of the %d cells): %s", length, command)
+		}
+		if end-start < int(length) {
+			cmd.start, cmd.end = uint8(start), uint8(end)
+		}
+		return cmd, nil
+	}
 	switch strings.ToUpper(command)[0] {
 	case 'R':
 		a, err := strconv.Atoi(command[1:])
 		if err != nil || a < 0 || a >= int(maze.Rows()) {
-			log.Fatal("Invalid shift:", err)
+			return Command{}, fmt.Errorf("Invalid shift: %s", command)
 		}
-		return Command{SLIDE_RIGHT, uint8(a)}
+		return Command{SLIDE_RIGHT, uint8(a), 0, 0, 0, 0}, nil
 	case 'L':
 		a, err := strconv.Atoi(command[1:])
 		if err != nil || a < 0 || a >= int(maze.Rows()) {
-			log.Fatal("Invalid shift:", err)
+			return Command{}, fmt.Errorf("Invalid shift: %s", command)
 		}
-		return Command{SLIDE_LEFT, uint8(a)}
-	case 'D':
+		return Command{SLIDE_LEFT, uint8(a), 0, 0, 0, 0}, nil
+	case 'D', 'C': // Column slides are written as C in the October solutions
 		a, err := strconv.Atoi(command[1:])
 		if err != nil || a < 0 || a >= int(maze.Columns()) {
-			log.Fatal("Invalid shift:", err)
+			return Command{}, fmt.Errorf("Invalid shift: %s", command)
 		}
-		return Command{SLIDE_DOWN, uint8(a)}
+		return Command{SLIDE_DOWN, uint8(a), 0, 0, 0, 0}, nil
 	case 'U':
 		a, err := strconv.Atoi(command[1:])
 		if err != nil || a < 0 || a >= int(maze.Columns()) {
-			log.Fatal("Invalid shift:", err)
+			return Command{}, fmt.Errorf("Invalid shift: %s", command)
 		}
-		return Command{SLIDE_UP, uint8(a)}
+		return Command{SLIDE_UP, uint8(a), 0, 0, 0, 0}, nil
 	case '(':
+		if !strings.HasSuffix(command, ")") {
+			return Command{}, fmt.Errorf("Invalid movement: %s", command)
+		}
 		args := strings.SplitN(command[1:len(command)-1], ",", 2)
+		if len(args) != 2 {
+			return Command{}, fmt.Errorf("Invalid movement: %s", command)
+		}
 		r, err := strconv.Atoi(args[0])
 		if err != nil {
-			log.Fatal("Invalid movement:", err)
+			return Command{}, fmt.Errorf("Invalid movement: %s", command)
 		}
 		c, err := strconv.Atoi(args[1])
 		if err != nil {
-			log.Fatal("Invalid movement:", err)
+			return Command{}, fmt.Errorf("Invalid movement: %s", command)
 		}
 		if r < 0 || r >= int(maze.Rows()) || c < 0 || c >= int(maze.Columns()) {
-			log.Fatal("Movement is out of boundaries:", command)
+			return Command{}, fmt.Errorf("Movement is out of boundaries: %s", command)
 		}
-		return Command{MOVE, uint8(r)*maze.Columns() + uint8(c)}
+		return Command{MOVE, uint8(r)*maze.Columns() + uint8(c), 0, 0, 0, 0}, nil
 	default:
-		log.Fatal("Can not parse command:", command)
-		return Command{0, 0}
+		return Command{}, fmt.Errorf("Can not parse command: %s", command)
 	}
 }
 
@@ -95,36 +209,95 @@ type Sequence struct {
 	location       uint8
 	command        Command
 	prev           *Sequence
+	rules          *Rules
+}
+
+func NewSequence(maze *Maze, turns uint8, rules *Rules) *Sequence {
+	return NewSequenceAt(maze, 0, turns, rules)
+}
+
+// NewSequenceAt starts with the player at the given location (rather than the top left cell)
+func NewSequenceAt(maze *Maze, location uint8, turns uint8, rules *Rules) *Sequence {
+	if rules != nil && maze.torus != rules.WrapMoves {
+		maze = maze.Wrap(rules.WrapMoves)
+	}
+	return &Sequence{turns, maze, location, Command{}, nil, rules}
 }
 
-func NewSequence(maze *Maze, turns uint8) *Sequence {
-	return &Sequence{turns, maze, 0, Command{}, nil}
+func (self *Sequence) Slide(command Command) (*Sequence, error) {
+	maze, err := self.maze.Slide(command)
+	if err != nil {
+		return nil, err
+	}
+	location := self.location
+	if self.rules.CarryPlayer {
+		location = self.maze.SlideLocation(command, location)
+	}
+	return &Sequence{
+		self.turnsRemaining - self.TurnCost(command),
+		maze,
+		location,
+		command,
+		self,
+		self.rules,
+	}, nil
 }
 
-func (self *Sequence) Slide(command Command) *Sequence {
+// Rotate turns the walls of a cell (leaving the player where they are)
+func (self *Sequence) Rotate(command Command) (*Sequence, error) {
+	maze, err := self.maze.Change(command)
+	if err != nil {
+		return nil, err
+	}
 	return &Sequence{
-		self.turnsRemaining - 1,
-		self.maze.Slide(command),
+		self.turnsRemaining - self.TurnCost(command),
+		maze,
 		self.location,
 		command,
 		self,
-	}
+		self.rules,
+	}, nil
 }
 
 func (self *Sequence) MoveSame() *Sequence {
-	return self.Move(Command{MOVE, self.location})
+	return self.Move(Command{MOVE, self.location, 0, 0, 0, 0})
 }
 
 func (self *Sequence) Move(command Command) *Sequence {
 	return &Sequence{
-		self.turnsRemaining - 1,
+		self.turnsRemaining - self.TurnCost(command),
 		self.maze,
 		command.argument,
 		command,
 		self,
+		self.rules,
 	}
 }
 
+// TurnCost is the number of turns used by running the given command next.  With paired turns a
+// slide is free when it follows a move (since the move has already used the turn).
+func (self *Sequence) TurnCost(command Command) uint8 {
+	if self.rules.PairedTurns && command.operation != MOVE && self.prev != nil && self.command.operation == MOVE {
+		return 0
+	}
+	return 1
+}
+
+// stateKey tells apart the states the game can be in (regardless of how they were arrived at).
+// Slides after a move are free with paired turns, so whether the last command was a move matters
+// as well as the maze and location.
+func (self *Sequence) stateKey() string {
+	return stateKey(self.maze, self.location, self.prev != nil && self.command.operation == MOVE)
+}
+
+func stateKey(maze *Maze, location uint8, moved bool) string {
+	return fmt.Sprint(maze.Pattern(), location, moved)
+}
+
+func (self *Sequence) TurnsRemaining() uint8 {
+	return self.turnsRemaining
+}
+
 func (self *Sequence) CanMove(newLocation uint8) bool {
 	for accessibleLocation := range self.maze.AccessibleLocations(self.location) {
 		if accessibleLocation == newLocation {
@@ -134,35 +307,84 @@ func (self *Sequence) CanMove(newLocation uint8) bool {
 	return false
 }
 
-func (self *Sequence) CanSlideHorizontal(row uint8) bool {
-	return self.location/self.maze.Columns() != row
+// Path is the cells walked by the last command (from where the player was to where it is now) or
+// nil if the last command wasn't a move
+func (self *Sequence) Path() []uint8 {
+	if self.prev == nil || self.command.operation != MOVE {
+		return nil
+	}
+	return self.maze.ShortestPath(self.prev.location, self.location)
+}
+
+// WalkedPaths is the path of every move from the start of the sequence (in order).  Slides may
+// carry the player between one path and the next.
+func (self *Sequence) WalkedPaths() [][]uint8 {
+	paths := [][]uint8{}
+	for s := self; s != nil; s = s.prev {
+		if path := s.Path(); path != nil {
+			paths = append([][]uint8{path}, paths...)
+		}
+	}
+	return paths
 }
 
-func (self *Sequence) CanSlideVertical(column uint8) bool {
-	return self.location%self.maze.Columns() != column
+// StepsWalked is the total number of cells the player has walked from the start of the sequence
+func (self *Sequence) StepsWalked() int {
+	steps := 0
+	for _, path := range self.WalkedPaths() {
+		steps += len(path) - 1
+	}
+	return steps
+}
+
+// CanSlide determines if the slide can be made: the player can't be in the cells slid if they are
+// locked, and no anchor can be in the way
+func (self *Sequence) CanSlide(command Command) bool {
+	if command.end > 0 && !self.rules.SegmentSlides {
+		return false
+	}
+	if self.rules.LockPlayer {
+		for _, location := range self.maze.line(command) {
+			if location == self.location {
+				return false
+			}
+		}
+	}
+	return !self.maze.Blocked(command)
 }
 
 func (self *Sequence) CanApply(command Command) bool {
+	if command.distance > 1 && (!self.rules.LongSlides || self.maze.hasSpare || command.distance >= self.maze.lineLength(command)) {
+		return false
+	}
 	switch command.operation {
 	case MOVE:
 		return self.CanMove(command.argument)
 	case SLIDE_RIGHT:
-		fallthrough
+		return self.CanSlide(command)
 	case SLIDE_LEFT:
-		return self.CanSlideHorizontal(command.argument)
+		return self.rules.ReverseSlides && self.CanSlide(command)
 	case SLIDE_DOWN:
-		fallthrough
+		return self.CanSlide(command)
 	case SLIDE_UP:
-		return self.CanSlideVertical(command.argument)
+		return self.rules.ReverseSlides && self.CanSlide(command)
+	case ROTATE:
+		return self.CanRotate(command.argument) && command.rotation%4 != 0
 	default:
 		return true
 	}
 }
 
-func (self *Sequence) Apply(command Command) *Sequence {
+func (self *Sequence) CanRotate(location uint8) bool {
+	return self.rules.RotateTiles && (!self.rules.LockPlayerTile || self.location != location) && !self.maze.Anchored(location)
+}
+
+// Apply runs the command, failing if it's for a row, column or cell outside the maze (though not
+// if the rules forbid it, see CanApply)
+func (self *Sequence) Apply(command Command) (*Sequence, error) {
 	switch command.operation {
 	case MOVE:
-		return self.Move(command)
+		return self.Move(command), nil
 	case SLIDE_RIGHT:
 		fallthrough
 	case SLIDE_LEFT:
@@ -171,8 +393,10 @@ func (self *Sequence) Apply(command Command) *Sequence {
 		fallthrough
 	case SLIDE_UP:
 		return self.Slide(command)
+	case ROTATE:
+		return self.Rotate(command)
 	default:
-		return self
+		return self, nil
 	}
 }
 
@@ -180,49 +404,35 @@ func (self *Sequence) CommandString() string {
 	return self.command.String(self.maze.Columns())
 }
 
-func (self *Sequence) CommandFromString(text string) Command {
+func (self *Sequence) CommandFromString(text string) (Command, error) {
 	return ParseCommand(self.maze, text)
 }
 
-func (self *Sequence) PrintSummary() {
-	var s strings.Builder
-
-	fmt.Println()
-	fmt.Println(colorize("yellow", "################################################################################"))
-	fmt.Println()
+// FprintSummary renders every step of the sequence (highlighting what each command changed)
+// followed by the solution
+func (self *Sequence) FprintSummary(w io.Writer, renderer Renderer, options RenderOptions) error {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, colorizeIf(options.Color, "yellow", "################################################################################"))
+	fmt.Fprintln(w)
 	stack := []*Sequence{}
 	for prev := self; prev != nil; prev = prev.prev {
 		stack = append([]*Sequence{prev}, stack...)
 	}
 	for i, prev := range stack {
 		if i > 0 {
-			s.WriteString(prev.CommandString())
-			s.WriteString(" ")
-			fmt.Println(">>>", prev.CommandString())
-		}
-		highlighter := func(row int, column int) bool {
-			cmdArg := int(prev.command.argument)
-			switch prev.command.operation {
-			case MOVE:
-				return cmdArg == row*int(prev.maze.Columns())+column
-			case SLIDE_RIGHT:
-				fallthrough
-			case SLIDE_LEFT:
-				return cmdArg == row
-			case SLIDE_DOWN:
-				fallthrough
-			case SLIDE_UP:
-				return cmdArg == column
-			default:
-				return false
-			}
+			fmt.Fprintln(w, ">>>", prev.CommandString())
+		}
+		options.Highlighter = prev.commandHighlighter()
+		if err := renderer.Render(w, prev.maze, prev.location, options); err != nil {
+			return err
 		}
-		prev.maze.Draw(prev.location, highlighter)
 	}
-	fmt.Println("SOLUTION:", colorize("green", s.String()))
+	_, err := fmt.Fprintln(w, "SOLUTION:", colorizeIf(options.Color, "green", self.SolutionString()))
+	return err
 }
 
-func (self *Sequence) Draw() {
+// SolutionString is the list of commands which have been run to arrive at this sequence
+func (self *Sequence) SolutionString() string {
 	var s strings.Builder
 
 	stack := []*Sequence{}
@@ -235,61 +445,193 @@ func (self *Sequence) Draw() {
 			s.WriteString(" ")
 		}
 	}
+	return s.String()
+}
 
-	highlighter := func(row int, column int) bool {
+// commandHighlighter highlights whatever was changed by the last command: the cell moved to or
+// the row/column slid
+func (self *Sequence) commandHighlighter() Highlighter {
+	return func(row int, column int) bool {
 		cmdArg := int(self.command.argument)
+		if self.command.operation != MOVE && self.maze.Anchored(uint8(row*int(self.maze.Columns())+column)) {
+			return false // Anchored cells never change
+		}
 		switch self.command.operation {
 		case MOVE:
 			return cmdArg == row*int(self.maze.Columns())+column
 		case SLIDE_RIGHT:
 			fallthrough
 		case SLIDE_LEFT:
-			return cmdArg == row
+			return cmdArg == row && self.command.covers(uint8(column))
 		case SLIDE_DOWN:
 			fallthrough
 		case SLIDE_UP:
-			return cmdArg == column
+			return cmdArg == column && self.command.covers(uint8(row))
+		case ROTATE:
+			return cmdArg == row*int(self.maze.Columns())+column
 		default:
 			return false
 		}
 	}
-	self.maze.Draw(self.location, highlighter)
-	fmt.Println("SOLUTION:", colorize("green", s.String()))
+}
+
+// Fdraw renders the current state of the maze followed by the solution
+func (self *Sequence) Fdraw(w io.Writer, renderer Renderer, options RenderOptions) error {
+	options.Highlighter = self.commandHighlighter()
+	if err := renderer.Render(w, self.maze, self.location, options); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "SOLUTION:", colorizeIf(options.Color, "green", self.SolutionString()))
+	return err
 }
 
 // Search implements Searchable interface for continuing the search from this sequence into a
 // subsequence sequence by taking an available (and legal) action
 func (self *Sequence) Search(onNext func(parallelsearch.Searchable)) {
-	if self.turnsRemaining > 0 {
-		cmd := self.command
-		if self.prev == nil || cmd.operation != MOVE {
-			for accessibleLocation := range self.maze.AccessibleLocations(self.location) {
-				if self.location != accessibleLocation {
-					onNext(self.Move(Command{MOVE, accessibleLocation}))
-				}
+	self.eachNext(func(cmd Command) {
+		if next, err := self.Apply(cmd); err == nil {
+			onNext(next)
+		}
+	})
+}
+
+// eachNext calls back with every legal command worth considering next.  Commands which can only
+// lead to the same states as others are skipped: moving twice in a row (since any location
+// reachable in two moves is reachable in one), moving to the current location and consecutive
+// slides in the same direction out of order (e.g. R1R0 is the same as R0R1).
+func (self *Sequence) eachNext(onNext func(Command)) {
+	cmd := self.command
+	try := func(next Command) {
+		if self.TurnCost(next) <= self.turnsRemaining && self.CanApply(next) {
+			onNext(next)
+		}
+	}
+
+	if self.prev == nil || cmd.operation != MOVE {
+		for accessibleLocation := range self.maze.AccessibleLocations(self.location) {
+			if self.location != accessibleLocation {
+				try(Command{MOVE, accessibleLocation, 0, 0, 0, 0})
 			}
 		}
-		for row := uint8(0); row < self.maze.Rows(); row++ {
-			// Canonicalize consecutive right slides (sorted by row)
+	}
+	for _, operation := range []uint8{SLIDE_RIGHT, SLIDE_LEFT, SLIDE_DOWN, SLIDE_UP} {
+		if self.rules.LongSlides && (operation == SLIDE_LEFT || operation == SLIDE_UP) {
+			continue // The same as sliding right/down by the rest of the row/column
+		}
+		limit := self.maze.Rows()
+		if operation == SLIDE_DOWN || operation == SLIDE_UP {
+			limit = self.maze.Columns()
+		}
+		for argument := uint8(0); argument < limit; argument++ {
+			// Canonicalize consecutive slides (sorted by row/column)
 			// This avoids duplicating redundant slides (e.g. R0R1 vs R1R0)
-			if cmd.operation != SLIDE_RIGHT || cmd.argument <= row {
-				if cmd.argument == 2 || cmd.argument == 4 || cmd.argument == 9 {
-					onNext(self.Slide(Command{SLIDE_RIGHT, row}))
+			// A spare tile passes from one slide to the next so they can't be reordered
+			// With long slides the same row/column is never slid twice in a row (one slide does both)
+			if self.prev == nil || cmd.operation != operation || cmd.argument < argument ||
+				(cmd.argument == argument && (!self.rules.LongSlides || self.rules.SegmentSlides)) || self.maze.hasSpare {
+				for _, segment := range self.segments(operation) {
+					for _, rotation := range self.maze.spareRotations() {
+						for _, distance := range self.slideDistances(Command{operation, argument, 0, 0, segment[0], segment[1]}) {
+							try(Command{operation, argument, rotation, distance, segment[0], segment[1]})
+						}
+					}
 				}
 			}
 		}
-		for column := uint8(0); column < self.maze.Columns(); column++ {
-			// Canonicalize consecutive down slides (sorted by column)
-			// This avoids duplicating redundant slides (e.g. C0C1 vs C1C0)
-			if cmd.operation != SLIDE_DOWN || cmd.argument <= column {
-				if cmd.argument == 0 || cmd.argument == 9 {
-					onNext(self.Slide(Command{SLIDE_LEFT, column}))
+	}
+	if self.rules.RotateTiles {
+		for location := uint8(0); location < self.maze.TotalCells(); location++ {
+			// Canonicalize consecutive rotations (sorted by cell) in the same way.  The same cell is
+			// never rotated twice in a row since a single rotation does the same.
+			if self.prev == nil || cmd.operation != ROTATE || cmd.argument < location {
+				for _, rotation := range self.maze.cellRotations(location) {
+					try(Command{ROTATE, location, rotation, 0, 0, 0})
 				}
 			}
 		}
 	}
 }
 
+// slideDistances are how far the slide (of its row/column or segment) can go (just one cell, written
+// as 0, unless the rules allow long slides)
+func (self *Sequence) slideDistances(slide Command) []uint8 {
+	distances := []uint8{0}
+	if !self.rules.LongSlides || self.maze.hasSpare {
+		return distances
+	}
+	length := self.maze.lineLength(slide)
+	for distance := uint8(2); distance < length; distance++ {
+		distances = append(distances, distance)
+	}
+	return distances
+}
+
+// segments are the parts of a row/column (from the first cell up to but not including the second)
+// which can be slid in the given direction: the whole row/column (written as 0:0) and, if the rules
+// allow it, every shorter segment of at least two cells
+func (self *Sequence) segments(operation uint8) [][2]uint8 {
+	segments := [][2]uint8{{0, 0}}
+	if !self.rules.SegmentSlides {
+		return segments
+	}
+	length := self.maze.lineLength(Command{operation, 0, 0, 0, 0, 0})
+	for start := uint8(0); start+1 < length; start++ {
+		for end := start + 2; end <= length; end++ {
+			if end-start < length {
+				segments = append(segments, [2]uint8{start, end})
+			}
+		}
+	}
+	return segments
+}
+
+// LegalCommands is every command which can be run next (within the turns remaining).  Nothing
+// can be run once the exit has been reached.
+func (self *Sequence) LegalCommands() []Command {
+	commands := []Command{}
+	if self.IsFound() {
+		return commands
+	}
+	try := func(next Command) {
+		if self.TurnCost(next) <= self.turnsRemaining && self.CanApply(next) {
+			commands = append(commands, next)
+		}
+	}
+
+	accessible := make([]bool, self.maze.TotalCells())
+	for location := range self.maze.AccessibleLocations(self.location) {
+		accessible[location] = true
+	}
+	for location := uint8(0); location < self.maze.TotalCells(); location++ {
+		if accessible[location] {
+			try(Command{MOVE, location, 0, 0, 0, 0})
+		}
+	}
+	for _, operation := range []uint8{SLIDE_RIGHT, SLIDE_LEFT, SLIDE_DOWN, SLIDE_UP} {
+		limit := self.maze.Rows()
+		if operation == SLIDE_DOWN || operation == SLIDE_UP {
+			limit = self.maze.Columns()
+		}
+		for argument := uint8(0); argument < limit; argument++ {
+			for _, segment := range self.segments(operation) {
+				for _, rotation := range self.maze.spareRotations() {
+					for _, distance := range self.slideDistances(Command{operation, argument, 0, 0, segment[0], segment[1]}) {
+						try(Command{operation, argument, rotation, distance, segment[0], segment[1]})
+					}
+				}
+			}
+		}
+	}
+	if self.rules.RotateTiles {
+		for location := uint8(0); location < self.maze.TotalCells(); location++ {
+			for _, rotation := range self.maze.cellRotations(location) {
+				try(Command{ROTATE, location, rotation, 0, 0, 0})
+			}
+		}
+	}
+	return commands
+}
+
 // IsFound implements Searchable interface to determine if the current sequence meets the goal
 // we are looking for
 func (self *Sequence) IsFound() bool {
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCommandRoundTrip(t *testing.T) {
+	maze := mustParseMaze(strings.Repeat("0", 80), 10)          // 8 rows of 10 columns
+	spareMaze := mustParseMaze(strings.Repeat("0", 80)+"5", 10) // The same with a spare tile
+	tests := []struct {
+		text  string
+		spare bool
+		want  Command
+		out   string // How it's written back (if not the same)
+	}{
+		{"(3,4)", false, Command{MOVE, 34, 0, 0, 0, 0}, ""},
+		{"(7,9)", false, Command{MOVE, 79, 0, 0, 0, 0}, ""},
+		{"R2", false, Command{SLIDE_RIGHT, 2, 0, 0, 0, 0}, ""},
+		{"L0", false, Command{SLIDE_LEFT, 0, 0, 0, 0, 0}, ""},
+		{"D9", false, Command{SLIDE_DOWN, 9, 0, 0, 0, 0}, ""},
+		{"C9", false, Command{SLIDE_DOWN, 9, 0, 0, 0, 0}, "D9"},
+		{"U1", false, Command{SLIDE_UP, 1, 0, 0, 0, 0}, ""},
+		{"R4x3", false, Command{SLIDE_RIGHT, 4, 0, 3, 0, 0}, ""},
+		{"R4x1", false, Command{SLIDE_RIGHT, 4, 0, 0, 0, 0}, "R4"},
+		{"U2x7", false, Command{SLIDE_UP, 2, 0, 7, 0, 0}, ""},
+		{"R2[3:7]", false, Command{SLIDE_RIGHT, 2, 0, 0, 3, 7}, ""},
+		{"D1[0:8]", false, Command{SLIDE_DOWN, 1, 0, 0, 0, 0}, "D1"},
+		{"L5[0:2]x1", false, Command{SLIDE_LEFT, 5, 0, 0, 0, 2}, "L5[0:2]"},
+		{"R2[3:7]x2", false, Command{SLIDE_RIGHT, 2, 0, 2, 3, 7}, ""},
+		{"R2+1", true, Command{SLIDE_RIGHT, 2, 1, 0, 0, 0}, ""},
+		{"U7+3", true, Command{SLIDE_UP, 7, 3, 0, 0, 0}, ""},
+		{"D0+0", true, Command{SLIDE_DOWN, 0, 0, 0, 0, 0}, "D0"},
+		{"L3x2+1", true, Command{SLIDE_LEFT, 3, 1, 2, 0, 0}, ""},
+		{"R2[3:7]x2+1", true, Command{SLIDE_RIGHT, 2, 1, 2, 3, 7}, ""},
+		{"T(2,3)", false, Command{ROTATE, 23, 1, 0, 0, 0}, "T(2,3)+1"},
+		{"T(2,3)+3", false, Command{ROTATE, 23, 3, 0, 0, 0}, ""},
+		{"t(0,0)+2", false, Command{ROTATE, 0, 2, 0, 0, 0}, "T(0,0)+2"},
+	}
+	for _, test := range tests {
+		m := maze
+		if test.spare {
+			m = spareMaze
+		}
+		got, err := ParseCommand(m, test.text)
+		if err != nil {
+			t.Errorf("%s: %s", test.text, err)
+			continue
+		} else if got != test.want {
+			t.Errorf("%s: parsed as %+v, expected %+v", test.text, got, test.want)
+		}
+		out := test.out
+		if out == "" {
+			out = test.text
+		}
+		if s := got.String(m.Columns()); s != out {
+			t.Errorf("%s: written as %s, expected %s", test.text, s, out)
+		} else if again, err := ParseCommand(m, s); err != nil || again != got {
+			t.Errorf("%s: %s parsed again as %+v (%v)", test.text, s, again, err)
+		}
+	}
+}
+
+func TestParseCommandErrors(t *testing.T) {
+	maze := mustParseMaze(strings.Repeat("0", 80), 10)
+	for _, text := range []string{
+		"", "X1", "R8", "D10", "R-1", "(8,0)", "(0,10)", "(1,2", "(1)",
+		"R2[3:4]", "R2[7:3]", "R2[0:11]", "R2[1:3", "R4x0", "R4x10", "D4x8",
+		"R2+1", "T(0,0)+0", "T(0,0)+4", "T(8,0)", "T2",
+	} {
+		if cmd, err := ParseCommand(maze, text); err == nil {
+			t.Errorf("%s: parsed as %+v, expected an error", text, cmd)
+		}
+	}
+}
+
+func TestWalkedPaths(t *testing.T) {
+	verification := Verify(mustParseMaze("43020c596163c1c9", 4), 3, NovemberRules, "(1,3) U2 (3,3)")
+	if !verification.Valid() {
+		t.Fatal(verification)
+	}
+	sequence := verification.Sequence
+	if paths := fmt.Sprint(sequence.WalkedPaths()); paths != "[[0 1 5 6 7] [7 6 10 11 15]]" {
+		t.Errorf("Walked %s", paths)
+	}
+	if path := sequence.prev.Path(); path != nil {
+		t.Errorf("Slide walked %v", path)
+	}
+	if steps := sequence.StepsWalked(); steps != 8 || verification.StepsWalked != steps {
+		t.Errorf("Walked %d (verified as %d) step(s)", steps, verification.StepsWalked)
+	}
+	if path := sequence.maze.ShortestPath(0, 3); path != nil {
+		t.Errorf("Walked %v to a cell which can't be reached", path)
+	}
+}
+
+// TestSegmentSlideDistances checks that a segment is never slid as far as its length or further
+// (which would only repeat a shorter slide)
+func TestSegmentSlideDistances(t *testing.T) {
+	rules := *SegmentRules
+	rules.LongSlides = true
+	maze := mustParseMaze(strings.Repeat("0", 20), 5)
+	sequence := NewSequence(maze, 3, &rules)
+	check := func(source string, commands []Command) {
+		long := 0
+		for _, cmd := range commands {
+			if cmd.distance > 1 {
+				long++
+				if cmd.distance >= maze.lineLength(cmd) {
+					t.Errorf("%s: %s slides too far", source, cmd.String(maze.Columns()))
+				}
+			}
+		}
+		if long == 0 {
+			t.Errorf("%s: no long slides", source)
+		}
+	}
+	check("legal", sequence.LegalCommands())
+	check("changes", changeCommands(maze, &rules))
+	next := []Command{}
+	sequence.eachNext(func(cmd Command) { next = append(next, cmd) })
+	check("next", next)
+
+	for _, cmd := range []Command{{SLIDE_RIGHT, 1, 0, 2, 1, 3}, {SLIDE_DOWN, 2, 0, 3, 0, 3}} {
+		if sequence.CanApply(cmd) {
+			t.Errorf("%s allowed", cmd.String(maze.Columns()))
+		}
+	}
+}
@@ -0,0 +1,170 @@
+//go:build !js
+
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Server is a JSON API (and browser front-end) for playing and solving mazes.  Requests carry the
+// scenario and every command played so far, so nothing but solve jobs is kept on the server.
+type Server struct {
+	MaxSolveTime time.Duration // Solve jobs are stopped after this long
+	FinishedTTL  time.Duration // Finished solve jobs which are never polled are forgotten after this long
+
+	mutex  sync.Mutex
+	jobs   map[string]*solveJob
+	nextID int
+}
+
+// solveJob is a solve running (or finished) in the background
+type solveJob struct {
+	ID string
+	apiSolution
+
+	solver *Solver
+	start  time.Time
+	done   chan struct{} // Closed once finished
+}
+
+func NewServer() *Server {
+	return &Server{MaxSolveTime: 5 * time.Minute, FinishedTTL: 10 * time.Minute, jobs: map[string]*solveJob{}}
+}
+
+// Handler serves the API under /api/ and the front-end everywhere else
+func (self *Server) Handler() http.Handler {
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	mux.HandleFunc("/api/maze", self.post(apiMaze))
+	mux.HandleFunc("/api/apply", self.post(apiApply))
+	mux.HandleFunc("/api/commands", self.post(apiCommands))
+	mux.HandleFunc("/api/solve", self.post(self.handleSolve))
+	mux.HandleFunc("/api/solve/", self.handleJob)
+	return mux
+}
+
+// post decodes the request body for a POST only API endpoint and encodes whatever it returns (or
+// the error as a bad request)
+func (self *Server) post(handle func(*apiRequest) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"Error": "POST only"})
+			return
+		}
+		request := &apiRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"Error": err.Error()})
+			return
+		}
+		response, err := handle(request)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"Error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleSolve starts searching for the shortest solution from the state of the request
+func (self *Server) handleSolve(request *apiRequest) (interface{}, error) {
+	sequence, err := request.sequence()
+	if err != nil {
+		return nil, err
+	}
+	solver := NewSolver()
+	if solver.Timeout, err = request.timeout(self.MaxSolveTime); err != nil {
+		return nil, err
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.nextID++
+	job := &solveJob{ID: fmt.Sprint(self.nextID), solver: solver, start: time.Now(), done: make(chan struct{})}
+	job.Status = "running"
+	self.jobs[job.ID] = job
+
+	go func() {
+		result := solver.Solve(sequence)
+		self.mutex.Lock()
+		job.apiSolution = newAPISolution(result)
+		self.mutex.Unlock()
+		time.AfterFunc(self.FinishedTTL, func() { self.forget(job.ID) })
+		close(job.done)
+	}()
+	return job.snapshot(), nil
+}
+
+// handleJob polls (GET) or cancels (DELETE) a solve job.  A job is forgotten once it has been
+// cancelled or polled after finishing.
+func (self *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/solve/")
+	self.mutex.Lock()
+	job, ok := self.jobs[id]
+	self.mutex.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"Error": "No such solve job: " + id})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		select {
+		case <-job.done:
+			self.forget(id)
+		default:
+		}
+	case http.MethodDelete:
+		job.solver.Cancel()
+		<-job.done
+		self.forget(id)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"Error": "GET or DELETE only"})
+		return
+	}
+
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	writeJSON(w, http.StatusOK, job.snapshot())
+}
+
+func (self *Server) forget(id string) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	delete(self.jobs, id)
+}
+
+// snapshot copies the job (with the server's mutex held) so it can be encoded safely
+func (self *solveJob) snapshot() solveJob {
+	job := *self
+	if job.Status == "running" {
+		job.Elapsed = time.Since(self.start).Round(time.Millisecond).String()
+	}
+	return job
+}
+
+// Serve runs the server until it fails
+func (self *Server) Serve(addr string) error {
+	fmt.Printf("Serving on http://%s\n", addr)
+	return http.ListenAndServe(addr, self.Handler())
+}
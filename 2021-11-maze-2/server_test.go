@@ -0,0 +1,125 @@
+//go:build !js
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const serverTestScenario = `"MazePattern": "43020c596163c1c9", "Columns": 4, "Rows": 4, "Turns": 3`
+
+// serverCall makes a request of the server returning the status and the decoded response
+func serverCall(t *testing.T, server *httptest.Server, method string, path string, body string) (int, map[string]interface{}) {
+	request, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var decoded interface{}
+	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		t.Fatalf("%s %s: %s", method, path, err)
+	}
+	object, _ := decoded.(map[string]interface{})
+	if object == nil {
+		object = map[string]interface{}{"List": decoded}
+	}
+	return response.StatusCode, object
+}
+
+func TestServerAPI(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		field              string // Which is checked in the response
+		want               interface{}
+	}{
+		{"POST", "/api/maze", `{` + serverTestScenario + `}`, 200, "TurnsRemaining", 3.0},
+		{"POST", "/api/maze", `{` + serverTestScenario + `, "Commands": ["(1,3)", "U2"]}`, 200, "Pattern", "43520c6961c3c109"},
+		{"POST", "/api/maze", `{` + serverTestScenario + `, "Commands": ["(3,3)"]}`, 400, "Error", "Step 1 ((3,3)): not allowed under november rules from (0,0)"},
+		{"POST", "/api/maze", `{`, 400, "Error", "unexpected EOF"},
+		{"GET", "/api/maze", ``, 405, "Error", "POST only"},
+		{"POST", "/api/apply", `{` + serverTestScenario + `, "Commands": ["(1,3)", "U2"], "Command": "(3,3)"}`, 200, "Solved", true},
+		{"POST", "/api/apply", `{` + serverTestScenario + `, "Command": "R0"}`, 400, "Error", "R0 is not allowed"},
+		{"POST", "/api/solve", `{` + serverTestScenario + `, "Timeout": "soon"}`, 400, "Error", `time: invalid duration "soon"`},
+		{"GET", "/api/solve/99", ``, 404, "Error", "No such solve job: 99"},
+	}
+	for _, test := range tests {
+		status, response := serverCall(t, server, test.method, test.path, test.body)
+		if status != test.status || response[test.field] != test.want {
+			t.Errorf("%s %s %s: %d %v, expected %d with %s %v", test.method, test.path, test.body, status, response, test.status, test.field, test.want)
+		}
+	}
+
+	_, response := serverCall(t, server, "POST", "/api/commands", `{`+serverTestScenario+`}`)
+	if commands, ok := response["List"].([]interface{}); !ok || len(commands) == 0 {
+		t.Errorf("Unexpected commands %v", response)
+	}
+}
+
+func TestServerSolveJobs(t *testing.T) {
+	s := NewServer()
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	// start begins solving the scenario with the given turns and waits for it to finish (without
+	// polling it)
+	start := func(turns string) string {
+		scenario := strings.Replace(serverTestScenario, `"Turns": 3`, `"Turns": `+turns, 1)
+		status, response := serverCall(t, server, "POST", "/api/solve", `{`+scenario+`}`)
+		id, _ := response["ID"].(string)
+		if status != 200 || id == "" {
+			t.Fatalf("Solve not started: %d %v", status, response)
+		}
+		s.mutex.Lock()
+		job := s.jobs[id]
+		s.mutex.Unlock()
+		<-job.done
+		return id
+	}
+
+	tests := []struct {
+		name   string
+		turns  string
+		ttl    time.Duration
+		wait   time.Duration // Before polling
+		method string
+		status int
+		result string // Status of the job (if found)
+	}{
+		{"solved", "3", time.Minute, 0, "GET", 200, "solved"},
+		{"unsolvable", "2", time.Minute, 0, "GET", 200, "unsolvable"},
+		{"cancelled after finishing", "3", time.Minute, 0, "DELETE", 200, "solved"},
+		{"expired", "3", 10 * time.Millisecond, 200 * time.Millisecond, "GET", 404, ""},
+	}
+	for _, test := range tests {
+		s.FinishedTTL = test.ttl
+		id := start(test.turns)
+		time.Sleep(test.wait)
+		status, response := serverCall(t, server, test.method, "/api/solve/"+id, "")
+		if status != test.status || (test.result != "" && response["Status"] != test.result) {
+			t.Errorf("%s: %d %v, expected %d %s", test.name, status, response, test.status, test.result)
+		}
+		// A finished job is forgotten once it has been seen
+		if status, _ := serverCall(t, server, "GET", "/api/solve/"+id, ""); status != 404 {
+			t.Errorf("%s: still found (%d) after being seen finished", test.name, status)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.jobs) != 0 {
+		t.Errorf("%d job(s) still kept", len(s.jobs))
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SessionNode is a sequence played during a session.  Undoing a command keeps it as a child so
+// that it can be redone, and playing a different command afterwards starts a new branch.
+type SessionNode struct {
+	sequence *Sequence
+	parent   *SessionNode
+	children []*SessionNode
+	redo     int // Index of the child which redo returns to
+}
+
+// Session is an interactive game of a scenario with unbounded, branching undo/redo history
+type Session struct {
+	scenario *Scenario
+	root     *SessionNode
+	current  *SessionNode
+}
+
+func NewSession(scenario *Scenario) (*Session, error) {
+	start, err := scenario.Sequence()
+	if err != nil {
+		return nil, err
+	}
+	root := &SessionNode{sequence: start}
+	return &Session{scenario, root, root}, nil
+}
+
+func (self *Session) Scenario() *Scenario {
+	return self.scenario
+}
+
+func (self *Session) Sequence() *Sequence {
+	return self.current.sequence
+}
+
+func (self *Session) TurnsUsed() int {
+	return int(self.scenario.Turns) - int(self.current.sequence.turnsRemaining)
+}
+
+// Apply runs the command if it is legal, returning the reason if it is not
+func (self *Session) Apply(command Command) error {
+	sequence := self.current.sequence
+	if sequence.IsFound() {
+		return fmt.Errorf("Already solved")
+	} else if sequence.TurnCost(command) > sequence.turnsRemaining {
+		return fmt.Errorf("No turns remaining")
+	} else if !sequence.CanApply(command) {
+		return fmt.Errorf("%s is not allowed", command.String(sequence.maze.Columns()))
+	}
+
+	// Replaying a command which was undone simply follows that branch again
+	for i, child := range self.current.children {
+		if child.sequence.command == command {
+			self.current.redo = i
+			self.current = child
+			return nil
+		}
+	}
+	next, err := sequence.Apply(command)
+	if err != nil {
+		return err
+	}
+	child := &SessionNode{sequence: next, parent: self.current}
+	self.current.children = append(self.current.children, child)
+	self.current.redo = len(self.current.children) - 1
+	self.current = child
+	return nil
+}
+
+func (self *Session) Undo() bool {
+	if self.current.parent == nil {
+		return false
+	}
+	self.current = self.current.parent
+	return true
+}
+
+func (self *Session) Redo() bool {
+	if len(self.current.children) == 0 {
+		return false
+	}
+	self.current = self.current.children[self.current.redo]
+	return true
+}
+
+// Branches lists the alternative commands which can be redone from the current position
+func (self *Session) Branches() []Command {
+	commands := make([]Command, len(self.current.children))
+	for i, child := range self.current.children {
+		commands[i] = child.sequence.command
+	}
+	return commands
+}
+
+// SwitchBranch changes which of the branches will be followed by redo
+func (self *Session) SwitchBranch(branch int) bool {
+	if branch < 0 || branch >= len(self.current.children) {
+		return false
+	}
+	self.current.redo = branch
+	return true
+}
+
+// History is the path from the start of the session to the current sequence (inclusive)
+// followed by the sequences which would be reached by repeatedly redoing.  The index of the
+// current sequence within the history is also returned.
+func (self *Session) History() ([]*Sequence, int) {
+	history := []*Sequence{}
+	for node := self.current; node != nil; node = node.parent {
+		history = append([]*Sequence{node.sequence}, history...)
+	}
+	current := len(history) - 1
+	for node := self.current; len(node.children) > 0; {
+		node = node.children[node.redo]
+		history = append(history, node.sequence)
+	}
+	return history, current
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sessionFile is how a session is saved: the scenario along with the tree of commands played
+// and the path of branches taken to the current position
+type sessionFile struct {
+	Scenario
+	Commands []*sessionFileNode
+	Current  []int
+}
+
+type sessionFileNode struct {
+	Command  string
+	Redo     bool               `json:",omitempty"`
+	Children []*sessionFileNode `json:",omitempty"`
+}
+
+func (self *Session) Save(path string) error {
+	columns := self.scenario.Columns
+
+	var toFile func(node *SessionNode) []*sessionFileNode
+	toFile = func(node *SessionNode) []*sessionFileNode {
+		nodes := []*sessionFileNode{}
+		for i, child := range node.children {
+			nodes = append(nodes, &sessionFileNode{
+				child.sequence.command.String(columns),
+				i == node.redo && len(node.children) > 1,
+				toFile(child),
+			})
+		}
+		return nodes
+	}
+
+	current := []int{}
+	for node := self.current; node.parent != nil; node = node.parent {
+		for i, child := range node.parent.children {
+			if child == node {
+				current = append([]int{i}, current...)
+			}
+		}
+	}
+
+	dat, err := json.MarshalIndent(&sessionFile{*self.scenario, toFile(self.root), current}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(dat, '\n'), 0644)
+}
+
+// LoadSession reads a saved session, replaying every command to rebuild its history
+func LoadSession(path string) (*Session, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := sessionFile{}
+	if err = json.Unmarshal(dat, &file); err != nil {
+		return nil, err
+	}
+
+	session, err := NewSession(&file.Scenario)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	var fromFile func(nodes []*sessionFileNode) error
+	fromFile = func(nodes []*sessionFileNode) error {
+		redo := 0
+		for i, node := range nodes {
+			cmd, err := session.Sequence().CommandFromString(node.Command)
+			if err == nil {
+				err = session.Apply(cmd)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %s after %s", path, err, session.Sequence().SolutionString())
+			}
+			if err = fromFile(node.Children); err != nil {
+				return err
+			}
+			session.Undo()
+			if node.Redo {
+				redo = i
+			}
+		}
+		session.SwitchBranch(redo)
+		return nil
+	}
+	if err = fromFile(file.Commands); err != nil {
+		return nil, err
+	}
+
+	for _, branch := range file.Current {
+		if !session.SwitchBranch(branch) || !session.Redo() {
+			return nil, fmt.Errorf("%s: invalid current position", path)
+		}
+	}
+	return session, nil
+}
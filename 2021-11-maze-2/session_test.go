@@ -0,0 +1,120 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// historyString is the history of a session written as its commands with the current position
+// marked by a *
+func historyString(session *Session) string {
+	history, current := session.History()
+	commands := []string{}
+	for i, sequence := range history[1:] {
+		command := sequence.CommandString()
+		if i+1 == current {
+			command += "*"
+		}
+		commands = append(commands, command)
+	}
+	return strings.Join(commands, " ")
+}
+
+func TestSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string // Commands to apply along with undo, redo and numbered branches to switch to
+		history  string
+		branches string // Which can be redone from the current position
+		solved   bool
+	}{
+		{"solve", "(1,3) U2 (3,3)", "(1,3) U2 (3,3)*", "", true},
+		{"undo", "(1,3) U2 undo", "(1,3)* U2", "U2", false},
+		{"undo everything", "(1,3) U2 undo undo undo", "(1,3) U2", "(1,3)", false},
+		{"redo", "(1,3) U2 undo undo redo", "(1,3)* U2", "U2", false},
+		{"branch", "(1,3) U2 undo D2", "(1,3) D2*", "", false},
+		{"switch back", "(1,3) U2 undo D2 undo 0 redo", "(1,3) U2*", "", false},
+		{"replay undone", "(1,3) U2 undo D2 undo U2 undo", "(1,3)* U2", "U2 D2", false},
+	}
+	for _, test := range tests {
+		session, err := NewSession(&Scenario{Turns: 3, Columns: 4, Rows: 4, MazePattern: "43020c596163c1c9", Rules: "november"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, step := range strings.Fields(test.script) {
+			switch step {
+			case "undo":
+				session.Undo()
+			case "redo":
+				session.Redo()
+			case "0", "1":
+				session.SwitchBranch(int(step[0] - '0'))
+			default:
+				cmd, err := session.Sequence().CommandFromString(step)
+				if err == nil {
+					err = session.Apply(cmd)
+				}
+				if err != nil {
+					t.Fatalf("%s: %s", test.name, err)
+				}
+			}
+		}
+
+		path := filepath.Join(t.TempDir(), "session.json")
+		if err := session.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadSession(path)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		for _, s := range []*Session{session, loaded} {
+			which := "played"
+			if s == loaded {
+				which = "loaded"
+			}
+			if history := historyString(s); history != test.history {
+				t.Errorf("%s: %s history is %s, expected %s", test.name, which, history, test.history)
+			}
+			branches := []string{}
+			for _, command := range s.Branches() {
+				branches = append(branches, command.String(4))
+			}
+			if strings.Join(branches, " ") != test.branches {
+				t.Errorf("%s: %s branches are %v, expected %s", test.name, which, branches, test.branches)
+			}
+			if s.Sequence().IsFound() != test.solved {
+				t.Errorf("%s: %s solved should be %v", test.name, which, test.solved)
+			}
+		}
+	}
+}
+
+func TestSessionApplyErrors(t *testing.T) {
+	tests := []struct {
+		script string
+		error  string
+	}{
+		{"(3,3)", "(3,3) is not allowed"},
+		{"U0", "U0 is not allowed"}, // The player's column is locked
+		{"D2 D2 D2 D2", "No turns remaining"},
+		{"(1,3) U2 (3,3) D0", "Already solved"},
+	}
+	for _, test := range tests {
+		session, err := NewSession(&Scenario{Turns: 3, Columns: 4, Rows: 4, MazePattern: "43020c596163c1c9", Rules: "november"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, step := range strings.Fields(test.script) {
+			cmd, _ := session.Sequence().CommandFromString(step)
+			if err = session.Apply(cmd); err != nil {
+				break
+			}
+		}
+		if err == nil || err.Error() != test.error {
+			t.Errorf("%s: expected error %q but got %v", test.script, test.error, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/david-mccullars/maze-ibm/parallelsearch"
+)
+
+// Solver searches for the shortest solution (fewest turns) from a sequence
+type Solver struct {
+	PoolSize int
+	Timeout  time.Duration // No limit if zero
+	Verbose  bool          // Announce each depth as it is finished
+	MinTurns uint8         // Budgets below this are skipped (e.g. proven too few by BackwardSearch)
+
+	mutex     sync.Mutex
+	search    *parallelsearch.ParallelSearch
+	cancelled bool
+}
+
+// SolveResult is the outcome of a search.  If no solution was found then either the search
+// timed out/was cancelled or it has shown that no solution exists within the turns remaining.
+type SolveResult struct {
+	Start     *Sequence
+	Solution  *Sequence // nil if not found
+	Turns     uint8     // Turns used by the solution (or the last budget fully searched)
+	Attempted uint8     // The budget being searched when the search stopped
+	Searched  []uint64  // Number of sequences searched at each depth (of the last search run)
+	Elapsed   time.Duration
+	TimedOut  bool
+	Cancelled bool
+}
+
+func NewSolver() *Solver {
+	return &Solver{PoolSize: 8 * runtime.NumCPU()}
+}
+
+// Solve searches with a budget of 0 turns (or MinTurns), then 1, etc. up to the turns remaining in
+// the start sequence.  Since every budget is searched exhaustively, the first solution found is
+// optimal.
+func (self *Solver) Solve(start *Sequence) *SolveResult {
+	result := &SolveResult{Start: start}
+	minTurns := self.MinTurns
+	if minTurns > start.turnsRemaining {
+		minTurns = start.turnsRemaining + 1 // Which proves there's no solution without searching
+	}
+	if minTurns > 0 {
+		result.Turns = minTurns - 1
+	}
+	began := time.Now()
+	var deadline <-chan time.Time
+	if self.Timeout > 0 {
+		timer := time.NewTimer(self.Timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for turns := minTurns; turns <= start.turnsRemaining; turns++ {
+		budget := *start
+		budget.turnsRemaining = turns
+		result.Attempted = turns
+
+		ps := parallelsearch.New(self.PoolSize, depthLimit(turns, start.rules), 1)
+		if !self.Verbose {
+			ps.OnDepthFinished(func(int, uint64) {})
+		}
+
+		self.mutex.Lock()
+		if self.cancelled {
+			self.mutex.Unlock()
+			result.Cancelled = true
+			break
+		}
+		self.search = ps
+		self.mutex.Unlock()
+
+		done := make(chan struct{})
+		timedOut := make(chan bool)
+		go func() {
+			select {
+			case <-deadline:
+				ps.Stop()
+				<-done
+				timedOut <- true
+			case <-done:
+				timedOut <- false
+			}
+		}()
+		ps.Start(&budget)
+		found := ps.WaitForFound()
+		close(done)
+		result.TimedOut = <-timedOut
+
+		result.Searched = ps.Searched()
+		if len(found) > 0 {
+			result.Solution = rebase(found[0].(*Sequence), &budget, start)
+			result.Turns = turns
+			break
+		} else if ps.Stopped() {
+			result.Cancelled = !result.TimedOut
+			break
+		}
+		result.Turns = turns
+	}
+
+	result.Elapsed = time.Since(began)
+	return result
+}
+
+// depthLimit is the most commands which can be run within the given number of turns
+func depthLimit(turns uint8, rules *Rules) int {
+	if rules.PairedTurns {
+		return 2*int(turns) + 1 // A move and a slide per turn (plus a slide after a move)
+	}
+	return int(turns)
+}
+
+// Cancel stops a solve running in the background
+func (self *Solver) Cancel() {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	self.cancelled = true
+	if self.search != nil {
+		self.search.Stop()
+	}
+}
+
+// rebase restores the turns remaining (and the link back to the start) of a solution which was
+// found using a smaller turn budget than the start actually has
+func rebase(solution *Sequence, budget *Sequence, start *Sequence) *Sequence {
+	if solution == budget {
+		return start
+	}
+	prev := rebase(solution.prev, budget, start)
+	rebased := *solution
+	rebased.turnsRemaining = prev.turnsRemaining - (solution.prev.turnsRemaining - solution.turnsRemaining)
+	rebased.prev = prev
+	return &rebased
+}
+
+// Exhausted determines if the search proved that no solution exists within the turns remaining
+func (self *SolveResult) Exhausted() bool {
+	return self.Solution == nil && !self.TimedOut && !self.Cancelled && self.Turns == self.Start.turnsRemaining
+}
+
+// Commands are the commands of the solution (after the start) or nil if no solution was found
+func (self *SolveResult) Commands() []Command {
+	if self.Solution == nil {
+		return nil
+	}
+	commands := []Command{}
+	for s := self.Solution; s != self.Start; s = s.prev {
+		commands = append([]Command{s.command}, commands...)
+	}
+	return commands
+}
+
+// NextCommand is the first command of the solution (after the start)
+func (self *SolveResult) NextCommand() (Command, bool) {
+	if self.Solution == nil || self.Solution == self.Start {
+		return Command{}, false
+	}
+	s := self.Solution
+	for s.prev != self.Start {
+		s = s.prev
+	}
+	return s.command, true
+}
+
+// Hint describes the result as a hint: only the next command of the solution is revealed
+func (self *SolveResult) Hint() string {
+	columns := self.Start.maze.Columns()
+	if cmd, ok := self.NextCommand(); ok {
+		return fmt.Sprintf("Try %s (solvable in %d turn(s))", cmd.String(columns), self.Turns)
+	} else if self.Solution != nil {
+		return "Already solved"
+	} else if self.Exhausted() {
+		return fmt.Sprintf("No solution in the %d turn(s) remaining", self.Start.turnsRemaining)
+	} else if self.TimedOut {
+		return fmt.Sprintf("No solution found within the time limit (gave up searching %d turn(s))", self.Attempted)
+	}
+	return "Hint cancelled"
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestHint(t *testing.T) {
+	start := NewSequence(mustParseMaze("43020c596163c1c9", 4), 3, NovemberRules)
+	solved := NewSolver().Solve(start)
+	tests := []struct {
+		result *SolveResult
+		hint   string
+	}{
+		{solved, "Try (1,3) (solvable in 3 turn(s))"},
+		{NewSolver().Solve(NewSequence(start.maze, 2, NovemberRules)), "No solution in the 2 turn(s) remaining"},
+		{&SolveResult{Start: start, TimedOut: true}, "No solution found within the time limit (gave up searching 0 turn(s))"},
+		{&SolveResult{Start: start, Turns: 1, Attempted: 2, TimedOut: true}, "No solution found within the time limit (gave up searching 2 turn(s))"},
+		{&SolveResult{Start: start, Cancelled: true}, "Hint cancelled"},
+	}
+	for _, test := range tests {
+		if hint := test.result.Hint(); hint != test.hint {
+			t.Errorf("%+v: hinted %q, expected %q", test.result, hint, test.hint)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// The browser keeps the scenario and the commands played: the server replays them for every
+// request so that it doesn't need to remember any games.
+
+const CELL = 40;
+
+let scenario = null;
+let commands = [];
+let state = null;
+let job = null;
+let solution = [];
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+async function api(method, path, body) {
+  const options = { method: method, headers: { 'Content-Type': 'application/json' } };
+  if (body) {
+    options.body = JSON.stringify(body);
+  }
+  const response = await fetch(path, options);
+  const result = await response.json();
+  if (!response.ok) {
+    throw new Error(result.Error);
+  }
+  return result;
+}
+
+function request(extra) {
+  return Object.assign({}, scenario, { Commands: commands }, extra);
+}
+
+function showError(err) {
+  $('error').textContent = err ? err.message : '';
+}
+
+async function load(event) {
+  event.preventDefault();
+  stopPolling();
+  scenario = {
+    MazePattern: $('pattern').value.trim(),
+    Rows: parseInt($('rows').value, 10),
+    Columns: parseInt($('columns').value, 10),
+    Turns: parseInt($('turns').value, 10),
+    Rules: $('rules').value,
+  };
+  commands = [];
+  setSolution([]);
+  await refresh(api('POST', '/api/maze', request()));
+}
+
+async function refresh(pending) {
+  try {
+    state = await pending;
+    commands = state.Commands;
+    showError(null);
+    draw();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+function legal(command) {
+  return state.Legal.some((c) => c.Command === command);
+}
+
+async function play(command) {
+  if (solution.length > 0 && solution[0] === command) {
+    setSolution(solution.slice(1));
+  } else {
+    setSolution([]);
+  }
+  await refresh(api('POST', '/api/apply', request({ Command: command })));
+}
+
+function draw() {
+  $('game').hidden = false;
+  const columns = state.Columns;
+  const rows = state.Rows;
+
+  // The maze is surrounded by buttons to slide each row and column
+  const table = $('slides');
+  table.innerHTML = '';
+  const slideButton = (label, command) => {
+    const td = document.createElement('td');
+    const button = document.createElement('button');
+    button.textContent = label;
+    button.title = command;
+    button.disabled = !legal(command);
+    button.onclick = () => play(command);
+    td.appendChild(button);
+    return td;
+  };
+  const edgeRow = (label, prefix) => {
+    const tr = table.insertRow();
+    tr.appendChild(document.createElement('td'));
+    for (let c = 0; c < columns; c++) {
+      tr.appendChild(slideButton(label, prefix + c)).style.width = CELL + 'px';
+    }
+    tr.appendChild(document.createElement('td'));
+  };
+
+  edgeRow('▲', 'U');
+  for (let r = 0; r < rows; r++) {
+    const tr = table.insertRow();
+    tr.style.height = CELL + 'px';
+    tr.appendChild(slideButton('◀', 'L' + r));
+    if (r === 0) {
+      const td = tr.insertCell();
+      td.rowSpan = rows;
+      td.colSpan = columns;
+      td.appendChild(drawMaze());
+    }
+    tr.appendChild(slideButton('▶', 'R' + r));
+  }
+  edgeRow('▼', 'D');
+
+  $('status').textContent = `Turns used: ${state.TurnsUsed}  Remaining: ${state.TurnsRemaining}` +
+    (state.Solved ? '  SOLVED!' : '');
+  const history = $('history');
+  history.innerHTML = '';
+  for (const command of commands) {
+    history.appendChild(document.createElement('li')).textContent = command;
+  }
+}
+
+function drawMaze() {
+  const columns = state.Columns;
+  const rows = state.Rows;
+  const canvas = document.createElement('canvas');
+  canvas.width = columns * CELL;
+  canvas.height = rows * CELL;
+  const ctx = canvas.getContext('2d');
+  const exit = rows * columns - 1;
+
+  for (let i = 0; i < rows * columns; i++) {
+    const x = (i % columns) * CELL;
+    const y = Math.floor(i / columns) * CELL;
+    if (state.Reachable.includes(i)) {
+      ctx.fillStyle = '#d5f5d5';
+      ctx.fillRect(x, y, CELL, CELL);
+    }
+    if (i === exit) {
+      ctx.fillStyle = '#f8c8c8';
+      ctx.fillRect(x, y, CELL, CELL);
+    }
+  }
+
+  // Each cell draws its own walls (N=8, E=4, S=2, W=1), so a wall is shown wherever either side
+  // of it is closed
+  ctx.strokeStyle = '#1f3a5f';
+  ctx.lineWidth = 4;
+  ctx.lineCap = 'square';
+  for (let i = 0; i < rows * columns; i++) {
+    const x = (i % columns) * CELL;
+    const y = Math.floor(i / columns) * CELL;
+    const b = parseInt(state.Pattern[i], 16);
+    const wall = (x1, y1, x2, y2) => {
+      ctx.beginPath();
+      ctx.moveTo(x1, y1);
+      ctx.lineTo(x2, y2);
+      ctx.stroke();
+    };
+    if (!(b & 8)) wall(x, y, x + CELL, y);
+    if (!(b & 4)) wall(x + CELL, y, x + CELL, y + CELL);
+    if (!(b & 2)) wall(x, y + CELL, x + CELL, y + CELL);
+    if (!(b & 1)) wall(x, y, x, y + CELL);
+  }
+
+  ctx.fillStyle = '#f2b805';
+  ctx.beginPath();
+  ctx.arc((state.Location % columns + 0.5) * CELL, (Math.floor(state.Location / columns) + 0.5) * CELL, CELL / 3, 0, 2 * Math.PI);
+  ctx.fill();
+
+  const cellAt = (event) => {
+    const rect = canvas.getBoundingClientRect();
+    const column = Math.floor((event.clientX - rect.left) / CELL);
+    const row = Math.floor((event.clientY - rect.top) / CELL);
+    return `(${row},${column})`;
+  };
+  canvas.onclick = (event) => {
+    const command = cellAt(event);
+    if (legal(command)) {
+      play(command);
+    }
+  };
+  // A right click turns the cell a quarter turn (when the rules allow it)
+  canvas.oncontextmenu = (event) => {
+    event.preventDefault();
+    const command = `T${cellAt(event)}+1`;
+    if (legal(command)) {
+      play(command);
+    }
+  };
+  return canvas;
+}
+
+function setSolution(commands) {
+  solution = commands;
+  $('next').hidden = solution.length === 0;
+}
+
+async function solve() {
+  try {
+    stopPolling();
+    setSolution([]);
+    job = await api('POST', '/api/solve', request({ Timeout: $('timeout').value }));
+    $('cancel').disabled = false;
+    poll();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+async function poll() {
+  const polling = job;
+  if (!polling) {
+    return;
+  }
+  let updated;
+  try {
+    updated = await api('GET', '/api/solve/' + polling.ID);
+  } catch (err) {
+    showError(err);
+    stopPolling();
+    return;
+  }
+  if (job !== polling) {
+    return; // Cancelled (or another solve started) in the meantime
+  }
+  job = updated;
+  showJob();
+  if (job.Status === 'running') {
+    setTimeout(poll, 500);
+  } else {
+    stopPolling();
+  }
+}
+
+function showJob() {
+  if (!job) {
+    return;
+  }
+  const searched = (job.Searched || []).reduce((a, b) => a + b, 0);
+  let text = `${job.Status} after ${job.Elapsed} (${searched} searched)`;
+  if (job.Status === 'solved') {
+    text = `Solved in ${job.Turns} turn(s): ${job.Solution.join(' ')}`;
+    setSolution(job.Solution);
+  } else if (job.Status === 'unsolvable') {
+    text = `No solution in the ${state.TurnsRemaining} turn(s) remaining`;
+  }
+  $('solution').textContent = text;
+}
+
+function stopPolling() {
+  job = null;
+  $('cancel').disabled = true;
+}
+
+async function cancel() {
+  if (!job) {
+    return;
+  }
+  const id = job.ID;
+  stopPolling();
+  try {
+    job = await api('DELETE', '/api/solve/' + id);
+    showJob();
+  } catch (err) {
+    showError(err);
+  }
+  job = null;
+}
+
+$('scenario').onsubmit = load;
+$('undo').onclick = () => {
+  setSolution([]);
+  refresh(api('POST', '/api/maze', Object.assign({}, scenario, { Commands: commands.slice(0, -1) })));
+};
+$('reset').onclick = () => {
+  setSolution([]);
+  refresh(api('POST', '/api/maze', Object.assign({}, scenario, { Commands: [] })));
+};
+$('solve').onclick = solve;
+$('cancel').onclick = cancel;
+$('next').onclick = () => play(solution[0]);
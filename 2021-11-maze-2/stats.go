@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MazeStats describe the shape of a maze (to judge a puzzle before searching it)
+type MazeStats struct {
+	Components   []Component // Cells connected by openings (largest first)
+	DeadEnds     []uint8     // Cells with a single opening
+	Degrees      [5]int      // How many cells have 0 to 4 openings
+	Nibbles      [16]int     // How many cells have each wall pattern
+	Slides       []SlideStats
+	TurnsToReach []int // Fewest turns after which the player can be in each cell (-1 if not within the limit)
+}
+
+// Component is a set of cells the player can walk between
+type Component struct {
+	Cells []uint8
+	Start bool // Contains the start (top left) cell
+	Exit  bool // Contains the exit (bottom right) cell
+}
+
+// SlideStats is how a single slide from the start changes the cells the player can walk to
+type SlideStats struct {
+	Slide  string
+	Before int // Cells the player can walk to before the slide
+	After  int // and after it
+	Gained int // Cells which can only be walked to after the slide
+	Lost   int // Cells which can only be walked to before the slide
+	Exit   bool
+}
+
+// NewMazeStats analyses the maze (with the player at the start).  The fewest turns needed to
+// reach each cell are only found if a turn limit is given, since every state within it is visited.
+func NewMazeStats(maze *Maze, rules *Rules, reachTurns uint8) *MazeStats {
+	maze = maze.Wrap(rules.WrapMoves)
+	stats := &MazeStats{Components: []Component{}, DeadEnds: []uint8{}, Slides: []SlideStats{}}
+	exit := maze.TotalCells() - 1
+
+	seen := make([]bool, maze.TotalCells())
+	for location := uint8(0); location < maze.TotalCells(); location++ {
+		degree := len(maze.openNeighbours(location))
+		stats.Degrees[degree]++
+		stats.Nibbles[maze.cells[location]]++
+		if degree == 1 {
+			stats.DeadEnds = append(stats.DeadEnds, location)
+		}
+		if !seen[location] {
+			component := Component{Cells: maze.component(location)}
+			for _, cell := range component.Cells {
+				seen[cell] = true
+				component.Start = component.Start || cell == 0
+				component.Exit = component.Exit || cell == exit
+			}
+			stats.Components = append(stats.Components, component)
+		}
+	}
+	sort.SliceStable(stats.Components, func(i, j int) bool {
+		return len(stats.Components[i].Cells) > len(stats.Components[j].Cells)
+	})
+
+	start := NewSequence(maze, 1, rules)
+	before := maze.component(start.location)
+	for _, cmd := range start.LegalCommands() {
+		if cmd.operation == MOVE {
+			continue
+		}
+		slid, err := start.Apply(cmd)
+		if err != nil {
+			continue
+		}
+		after := slid.maze.component(slid.location)
+		slide := SlideStats{Slide: cmd.String(maze.Columns()), Before: len(before), After: len(after)}
+		inBefore := make([]bool, maze.TotalCells())
+		for _, cell := range before {
+			inBefore[cell] = true
+		}
+		for _, cell := range after {
+			if inBefore[cell] {
+				inBefore[cell] = false
+			} else {
+				slide.Gained++
+			}
+			slide.Exit = slide.Exit || cell == exit
+		}
+		for _, cell := range inBefore {
+			if cell {
+				slide.Lost++
+			}
+		}
+		stats.Slides = append(stats.Slides, slide)
+	}
+
+	if reachTurns > 0 {
+		stats.TurnsToReach = turnsToReach(maze, rules, reachTurns)
+	}
+	return stats
+}
+
+// component is every cell the player can walk to from the given location (in order)
+func (self *Maze) component(location uint8) []uint8 {
+	accessible := make([]bool, self.TotalCells())
+	for cell := range self.AccessibleLocations(location) {
+		accessible[cell] = true
+	}
+	cells := []uint8{}
+	for cell, ok := range accessible {
+		if ok {
+			cells = append(cells, uint8(cell))
+		}
+	}
+	return cells
+}
+
+// turnsToReach visits every state within the turn limit (fewest turns first), recording the
+// fewest turns after which the player is in each cell
+func turnsToReach(maze *Maze, rules *Rules, limit uint8) []int {
+	reached := make([]int, maze.TotalCells())
+	for i := range reached {
+		reached[i] = -1
+	}
+
+	key := (*Sequence).stateKey
+	start := NewSequence(maze, limit, rules)
+	best := map[string]uint8{key(start): limit} // Most turns remaining each state has been queued with
+	queue := []*Sequence{start}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if best[key(s)] > s.turnsRemaining {
+			continue // Since queued again with more turns remaining
+		}
+		if turns := int(limit - s.turnsRemaining); reached[s.location] < 0 || turns < reached[s.location] {
+			reached[s.location] = turns
+		}
+		for _, cmd := range s.LegalCommands() {
+			next, err := s.Apply(cmd)
+			if err != nil {
+				continue
+			}
+			if remaining, ok := best[key(next)]; ok && remaining >= next.turnsRemaining {
+				continue
+			}
+			best[key(next)] = next.turnsRemaining
+			if next.turnsRemaining == s.turnsRemaining {
+				queue = append([]*Sequence{next}, queue...) // Free, so visited before the rest
+			} else {
+				queue = append(queue, next)
+			}
+		}
+	}
+	return reached
+}
+
+// Fprint writes the statistics as text
+func (self *MazeStats) Fprint(w io.Writer, maze *Maze) {
+	columns := maze.Columns()
+	cells := func(locations []uint8) string {
+		s := []string{}
+		for _, location := range locations {
+			s = append(s, Command{MOVE, location, 0, 0, 0, 0}.String(columns))
+		}
+		return strings.Join(s, " ")
+	}
+
+	fmt.Fprintf(w, "COMPONENTS: %d\n", len(self.Components))
+	for _, component := range self.Components {
+		label := ""
+		if component.Start {
+			label += " start"
+		}
+		if component.Exit {
+			label += " exit"
+		}
+		fmt.Fprintf(w, "  %d cell(s)%s: %s\n", len(component.Cells), label, cells(component.Cells))
+	}
+	fmt.Fprintf(w, "DEAD ENDS: %d %s\n", len(self.DeadEnds), cells(self.DeadEnds))
+	fmt.Fprint(w, "DEGREES:")
+	for degree, count := range self.Degrees {
+		fmt.Fprintf(w, " %d:%d", degree, count)
+	}
+	fmt.Fprint(w, "\nNIBBLES:")
+	for nibble, count := range self.Nibbles {
+		if count > 0 {
+			fmt.Fprintf(w, " %x:%d", nibble, count)
+		}
+	}
+	fmt.Fprintln(w, "\nSLIDES: (cells the player can walk to)")
+	for _, slide := range self.Slides {
+		exit := ""
+		if slide.Exit {
+			exit = " (reaches the exit)"
+		}
+		fmt.Fprintf(w, "  %-4s %d -> %d (+%d -%d)%s\n", slide.Slide, slide.Before, slide.After, slide.Gained, slide.Lost, exit)
+	}
+
+	if self.TurnsToReach != nil {
+		fmt.Fprintln(w, "TURNS TO REACH:")
+		for location, turns := range self.TurnsToReach {
+			if location%int(columns) == 0 {
+				fmt.Fprint(w, " ")
+			}
+			if turns < 0 {
+				fmt.Fprint(w, "  -")
+			} else {
+				fmt.Fprintf(w, " %2d", turns)
+			}
+			if (location+1)%int(columns) == 0 {
+				fmt.Fprintln(w)
+			}
+		}
+	}
+}
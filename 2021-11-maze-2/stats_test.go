@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMazeStats(t *testing.T) {
+	maze := mustParseMaze("43020c596163c1c9", 4)
+	want := `
+COMPONENTS: 5
+  6 cell(s) start: (0,0) (0,1) (0,3) (1,1) (1,2) (1,3)
+  4 cell(s): (2,0) (2,1) (3,0) (3,1)
+  4 cell(s) exit: (2,2) (2,3) (3,2) (3,3)
+  1 cell(s): (0,2)
+  1 cell(s): (1,0)
+DEAD ENDS: 4 (0,0) (0,3) (2,1) (3,1)
+DEGREES: 0:2 1:4 2:10 3:0 4:0
+NIBBLES: 0:2 1:2 2:1 3:2 4:1 5:1 6:2 9:2 c:3
+SLIDES: (cells the player can walk to)
+  R1   6 -> 2 (+0 -4)
+  R2   6 -> 6 (+0 -0)
+  R3   6 -> 6 (+0 -0)
+  L1   6 -> 2 (+0 -4)
+  L2   6 -> 6 (+0 -0)
+  L3   6 -> 6 (+0 -0)
+  D1   6 -> 2 (+0 -4)
+  D2   6 -> 3 (+0 -3)
+  D3   6 -> 4 (+0 -2)
+  U1   6 -> 1 (+0 -5)
+  U2   6 -> 3 (+0 -3)
+  U3   6 -> 8 (+3 -1)
+TURNS TO REACH:
+   0  1  -  1
+   3  1  1  1
+   -  -  2  2
+   -  -  2  3
+`
+	var b strings.Builder
+	NewMazeStats(maze, NovemberRules, 3).Fprint(&b, maze)
+	if got := b.String(); got != want[1:] {
+		t.Errorf("Statistics written as\n%s\nexpected\n%s", got, want[1:])
+	}
+
+	// The fewest turns to reach each cell are only found if asked for
+	b.Reset()
+	NewMazeStats(maze, NovemberRules, 0).Fprint(&b, maze)
+	if got := want[1:strings.Index(want, "TURNS TO REACH:")]; b.String() != got {
+		t.Errorf("Statistics without turns to reach written as\n%s", b.String())
+	}
+}
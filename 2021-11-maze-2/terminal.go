@@ -0,0 +1,49 @@
+//go:build !js
+
+package main
+
+import (
+	"log"
+	"os"
+)
+
+// Everything which writes straight to stdout (or exits) is left out of the WebAssembly build
+
+func NewMaze(mazePattern string, columns uint8) *Maze {
+	maze, err := ParseMaze(mazePattern, columns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return maze
+}
+
+// Draw prints the maze to stdout in the original block style
+func (self *Maze) Draw(currentLocation uint8, highlighter Highlighter) {
+	options := StdoutRenderOptions()
+	options.Highlighter = highlighter
+	BlockRenderer{}.Render(os.Stdout, self, currentLocation, options)
+}
+
+func (self *Sequence) PrintSummary() {
+	self.FprintSummary(os.Stdout, BlockRenderer{}, StdoutRenderOptions())
+}
+
+func (self *Sequence) Draw() {
+	self.Fdraw(os.Stdout, BlockRenderer{}, StdoutRenderOptions())
+}
+
+// StdoutRenderOptions are the options used when printing to stdout (in color if it's a terminal)
+func StdoutRenderOptions() RenderOptions {
+	return RenderOptions{Color: IsColorTerminal()}
+}
+
+func colorize(colorName string, a ...interface{}) string {
+	return colorizeIf(IsColorTerminal(), colorName, a...)
+}
+
+// IsColorTerminal determines if stdout is a terminal (rather than a pipe or file) and so can be
+// written to in color
+func IsColorTerminal() bool {
+	fileInfo, _ := os.Stdout.Stat()
+	return (fileInfo.Mode() & os.ModeCharDevice) != 0
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var colorCodes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// TranscriptFrame is a single board drawn in a transcript along with the command (if any) which
+// was printed just before it
+type TranscriptFrame struct {
+	Command  string
+	Maze     *Maze
+	Location uint8
+	Line     int // Line number of the top edge of the board
+}
+
+// Transcript is the captured output of PrintSummary (or of a play session): a list of boards
+// drawn by Maze.Draw, each preceded by a ">>> CMD" header
+type Transcript struct {
+	Frames []*TranscriptFrame
+}
+
+// StripColorCodes removes any ANSI color codes (as written by colorize) from the given text
+func StripColorCodes(text string) string {
+	return colorCodes.ReplaceAllString(text, "")
+}
+
+// ReadTranscript parses a transcript from the given reader.  Color codes are ignored, so both
+// terminal captures and plain-text output can be read.
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	transcript := &Transcript{}
+	command := ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	nextLine := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		lineNumber++
+		return StripColorCodes(scanner.Text()), true
+	}
+
+	for {
+		line, ok := nextLine()
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(line, ">>> ") {
+			command = strings.TrimSpace(line[4:])
+			continue
+		} else if !strings.HasPrefix(line, "__") {
+			continue // Prompts, SOLUTION lines, etc.
+		}
+
+		top := lineNumber
+		width := len([]rune(line)) - 2
+		if width <= 0 || width%6 != 0 {
+			return nil, fmt.Errorf("line %d: board edge has unexpected width %d", top, width+2)
+		}
+		columns := width / 6
+
+		rows := [][]rune{}
+		for {
+			line, ok = nextLine()
+			if !ok {
+				return nil, fmt.Errorf("line %d: board is not terminated", top)
+			}
+			if strings.HasPrefix(line, "¯") {
+				break
+			}
+			rows = append(rows, []rune(line))
+		}
+
+		frame, err := parseTranscriptBoard(rows, columns)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", top, err)
+		}
+		frame.Command = command
+		frame.Line = top
+		transcript.Frames = append(transcript.Frames, frame)
+		command = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(transcript.Frames) == 0 {
+		return nil, fmt.Errorf("transcript does not contain any boards")
+	}
+	return transcript, nil
+}
+
+// parseTranscriptBoard rebuilds the maze from the lines drawn by Maze.Draw.  Each cell is three
+// lines of three 2-character blocks where an opening is drawn as blanks.
+func parseTranscriptBoard(lines [][]rune, columns int) (*TranscriptFrame, error) {
+	if len(lines)%3 != 0 {
+		return nil, fmt.Errorf("board has %d lines which is not a multiple of 3", len(lines))
+	}
+	if columns*len(lines)/3 > 255 {
+		return nil, fmt.Errorf("board is too large")
+	}
+
+	isOpen := func(line []rune, idx int) bool {
+		return line[idx] == ' ' && line[idx+1] == ' '
+	}
+
+	cells := make([]byte, 0, columns*len(lines)/3)
+	location := -1
+	for r := 0; r < len(lines); r += 3 {
+		for i := r; i < r+3; i++ {
+			if len(lines[i]) != columns*6+2 {
+				return nil, fmt.Errorf("board line %d has unexpected width %d", i+1, len(lines[i]))
+			}
+		}
+		s1, s2, s3 := lines[r], lines[r+1], lines[r+2]
+		for c := 0; c < columns; c++ {
+			idx := 1 + c*6
+			b := byte(0)
+			if isOpen(s1, idx+2) {
+				b |= 8
+			}
+			if isOpen(s2, idx+4) {
+				b |= 4
+			}
+			if isOpen(s3, idx+2) {
+				b |= 2
+			}
+			if isOpen(s2, idx) {
+				b |= 1
+			}
+			if s2[idx+2] == '¥' {
+				location = len(cells)
+			}
+			cells = append(cells, b)
+		}
+	}
+	if location < 0 {
+		return nil, fmt.Errorf("board does not show the player")
+	}
+
+	return &TranscriptFrame{
+		Maze:     &Maze{cells, uint8(columns), 0, false, nil, false},
+		Location: uint8(location),
+	}, nil
+}
+
+// TranscriptMismatch describes the first frame of a transcript which can not be reproduced by
+// replaying its commands
+type TranscriptMismatch struct {
+	Step   int
+	Frame  *TranscriptFrame
+	Reason string
+}
+
+func (self *TranscriptMismatch) Error() string {
+	return fmt.Sprintf("step %d (>>> %s at line %d): %s", self.Step, self.Frame.Command, self.Frame.Line, self.Reason)
+}
+
+// Replay starts from the first board of the transcript and applies each subsequent command,
+// comparing the engine's state with the drawn board.  The resulting sequence is returned along
+// with the first mismatch found (if any).  A sequence only holds up to 255 turns, so the turns of
+// a longer transcript are topped up as they run out.
+func (self *Transcript) Replay(turns int, rules *Rules) (*Sequence, *TranscriptMismatch) {
+	// budget takes up to 255 of the turns left
+	budget := func() uint8 {
+		taken := turns
+		if taken > 255 {
+			taken = 255
+		}
+		turns -= taken
+		return uint8(taken)
+	}
+	first := self.Frames[0]
+	sequence := NewSequence(first.Maze, budget(), rules)
+	sequence.location = first.Location
+
+	for step, frame := range self.Frames[1:] {
+		mismatch := func(format string, a ...interface{}) *TranscriptMismatch {
+			return &TranscriptMismatch{step + 1, frame, fmt.Sprintf(format, a...)}
+		}
+		if sequence.turnsRemaining == 0 {
+			sequence.turnsRemaining = budget()
+		}
+
+		if frame.Command == "" {
+			return sequence, mismatch("board has no command")
+		}
+		cmd, err := sequence.CommandFromString(frame.Command)
+		if err != nil {
+			return sequence, mismatch("%s", err)
+		}
+		if !sequence.CanApply(cmd) {
+			return sequence, mismatch("command is not allowed")
+		}
+		if sequence.TurnCost(cmd) > sequence.turnsRemaining {
+			return sequence, mismatch("no turns remaining")
+		}
+		next, err := sequence.Apply(cmd)
+		if err != nil {
+			return sequence, mismatch("%s", err)
+		}
+		sequence = next
+
+		if frame.Maze.Columns() != sequence.maze.Columns() || frame.Maze.TotalCells() != sequence.maze.TotalCells() {
+			return sequence, mismatch("board dimensions changed")
+		}
+		if frame.Location != sequence.location {
+			return sequence, mismatch("player drawn at %s but engine has %s",
+				Command{MOVE, frame.Location, 0, 0, 0, 0}.String(sequence.maze.Columns()),
+				Command{MOVE, sequence.location, 0, 0, 0, 0}.String(sequence.maze.Columns()))
+		}
+		for i, b := range sequence.maze.cells {
+			if frame.Maze.cells[i] != b {
+				return sequence, mismatch("cell %s drawn as %x but engine has %x",
+					Command{MOVE, uint8(i), 0, 0, 0, 0}.String(sequence.maze.Columns()), frame.Maze.cells[i], b)
+			}
+		}
+	}
+	return sequence, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReplayTranscripts replays the transcripts checked in alongside the code.  They were captured
+// when sliding a row left dropped its first cell (repeating another in its place), so each should
+// reproduce every board up to its first left slide and then differ from it as expected.
+func TestReplayTranscripts(t *testing.T) {
+	tests := []struct {
+		prefix   string // Of the maze pattern naming the transcript
+		steps    int
+		solution string
+		mismatch string
+	}{
+		{"593eaacd", 34, "D1 U2 U2 L4 L4 L4 L4 L6 L6 L6 L6 L6 L6 R9 U5 U6 U7 D9 D9 D9 D9 U10 U10 U10 D11 D11 D11 D12 D12 D12 D12 D13 U14 (14,14)",
+			"step 4 (>>> L4 at line 194): cell (4,12) drawn as 9 but engine has 5"},
+		{"63aaac95", 11, "L2 L3 D5 L4 L4 L5 L6 D5 U7 U9 (9,9)",
+			"step 1 (>>> L2 at line 35): cell (2,7) drawn as d but engine has 9"},
+	}
+	files, err := filepath.Glob("*.txt")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) != len(tests) {
+		t.Fatalf("Found %d transcript(s) but expected %d", len(files), len(tests))
+	}
+
+	for _, test := range tests {
+		files, _ := filepath.Glob(test.prefix + "*.txt")
+		if len(files) != 1 {
+			t.Errorf("%s: transcript not found", test.prefix)
+			continue
+		}
+		f, err := os.Open(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		transcript, err := ReadTranscript(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %s", test.prefix, err)
+			continue
+		}
+		if steps := len(transcript.Frames) - 1; steps != test.steps {
+			t.Errorf("%s: %d step(s) read but expected %d", test.prefix, steps, test.steps)
+		}
+
+		sequence, mismatch := transcript.Replay(test.steps, NovemberRules)
+		if mismatch == nil || mismatch.Error() != test.mismatch {
+			t.Errorf("%s: replay stopped with %v, expected %s", test.prefix, mismatch, test.mismatch)
+		} else if replayed := sequence.SolutionString(); !strings.HasPrefix(test.solution, replayed) {
+			t.Errorf("%s: replayed %s", test.prefix, replayed)
+		}
+	}
+}
+
+// TestReplayLongTranscript checks that a transcript can have more steps than a sequence has turns
+func TestReplayLongTranscript(t *testing.T) {
+	maze := mustParseMaze("43020c596163c1c9", 4)
+	transcript := &Transcript{Frames: []*TranscriptFrame{{Maze: maze}}}
+	slides := []Command{{SLIDE_RIGHT, 1, 0, 0, 0, 0}, {SLIDE_LEFT, 1, 0, 0, 0, 0}}
+	for i := 0; i < 300; i++ {
+		slide := slides[i%2]
+		var err error
+		if maze, err = maze.Slide(slide); err != nil {
+			t.Fatal(err)
+		}
+		transcript.Frames = append(transcript.Frames, &TranscriptFrame{Command: slide.String(4), Maze: maze, Line: i + 1})
+	}
+
+	sequence, mismatch := transcript.Replay(300, NovemberRules)
+	if mismatch != nil {
+		t.Fatal(mismatch)
+	}
+	if commands := len(strings.Fields(sequence.SolutionString())); commands != 300 {
+		t.Errorf("Replayed %d command(s)", commands)
+	}
+	if _, mismatch := transcript.Replay(299, NovemberRules); mismatch == nil || mismatch.Step != 300 {
+		t.Errorf("Replayed with a turn too few: %v", mismatch)
+	}
+}
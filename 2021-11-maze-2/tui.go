@@ -0,0 +1,317 @@
+//go:build !js
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// TUI is a full-screen terminal interface for playing a maze interactively
+type TUI struct {
+	session     *Session
+	sessionPath string // Where the session is saved (if anywhere)
+	cursor      uint8
+	message     string
+	hintTimeout time.Duration
+	hintSolver  *Solver // The hint being searched for in the background (if any)
+	hints       chan *SolveResult
+
+	renderer      Renderer
+	renderOptions RenderOptions
+}
+
+func NewTUI(session *Session, sessionPath string, hintTimeout time.Duration) *TUI {
+	return &TUI{
+		session:     session,
+		sessionPath: sessionPath,
+		hintTimeout: hintTimeout,
+		hints:       make(chan *SolveResult),
+		renderer:    BlockRenderer{},
+	}
+}
+
+// SetStyle changes how the maze is drawn
+func (self *TUI) SetStyle(renderer Renderer, options RenderOptions) {
+	self.renderer = renderer
+	self.renderOptions = options
+}
+
+func (self *TUI) Sequence() *Sequence {
+	return self.session.Sequence()
+}
+
+// Run takes over the terminal until the player quits and returns the last sequence played
+func (self *TUI) Run() (*Sequence, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(fd, state)
+
+	fmt.Print("\x1b[?1049h\x1b[?25l") // Alternate screen and hide cursor
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan string)
+	errs := make(chan error)
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				errs <- err
+				return
+			}
+			keys <- string(buf[:n])
+		}
+	}()
+	defer self.cancelHint()
+
+	for {
+		self.redraw()
+		select {
+		case key := <-keys:
+			if !self.handleKey(key) {
+				return self.Sequence(), nil
+			}
+		case hint := <-self.hints:
+			self.showHint(hint)
+		case err := <-errs:
+			return self.Sequence(), err
+		}
+	}
+}
+
+// handleKey updates the state for a key press and returns false if the player has quit
+func (self *TUI) handleKey(key string) bool {
+	sequence := self.Sequence()
+	maze := sequence.maze
+	row := self.cursor / maze.Columns()
+	column := self.cursor % maze.Columns()
+	self.message = ""
+
+	switch key {
+	case "\x1b[A":
+		if row > 0 {
+			self.cursor -= maze.Columns()
+		}
+	case "\x1b[B":
+		if row+1 < maze.Rows() {
+			self.cursor += maze.Columns()
+		}
+	case "\x1b[C":
+		if column+1 < maze.Columns() {
+			self.cursor++
+		}
+	case "\x1b[D":
+		if column > 0 {
+			self.cursor--
+		}
+	case "\r", "\n", " ":
+		self.apply(Command{MOVE, self.cursor, 0, 0, 0, 0})
+	case "r", "R":
+		self.apply(Command{SLIDE_RIGHT, row, 0, 0, 0, 0})
+	case "l", "L":
+		self.apply(Command{SLIDE_LEFT, row, 0, 0, 0, 0})
+	case "d", "D":
+		self.apply(Command{SLIDE_DOWN, column, 0, 0, 0, 0})
+	case "u", "U":
+		self.apply(Command{SLIDE_UP, column, 0, 0, 0, 0})
+	case "t", "T":
+		self.apply(Command{ROTATE, self.cursor, 1, 0, 0, 0})
+	case "z", "Z":
+		if !self.session.Undo() {
+			self.message = "NOTHING TO UNDO"
+		}
+	case "y", "Y":
+		if !self.session.Redo() {
+			self.message = "NOTHING TO REDO"
+		}
+	case "b", "B":
+		self.switchBranch()
+	case "w", "W":
+		self.save()
+	case "h", "H":
+		self.startHint()
+	case "q", "Q", "\x1b", "\x03", "\x04":
+		return false
+	}
+	return true
+}
+
+func (self *TUI) apply(command Command) {
+	if err := self.session.Apply(command); err != nil {
+		self.message = strings.ToUpper(err.Error())
+	}
+}
+
+// startHint searches for a solution from the current sequence in the background
+func (self *TUI) startHint() {
+	if self.hintSolver != nil {
+		self.message = "STILL SEARCHING FOR A HINT"
+		return
+	}
+	solver := NewSolver()
+	solver.Timeout = self.hintTimeout
+	self.hintSolver = solver
+	self.message = "SEARCHING FOR A HINT..."
+
+	start := self.Sequence()
+	go func() {
+		self.hints <- solver.Solve(start)
+	}()
+}
+
+func (self *TUI) showHint(hint *SolveResult) {
+	self.hintSolver = nil
+	if hint.Start != self.Sequence() {
+		self.message = "HINT DISCARDED (THE MAZE HAS CHANGED)"
+	} else {
+		self.message = strings.ToUpper(hint.Hint())
+	}
+}
+
+// cancelHint stops any hint being searched for, waiting for the search to finish
+func (self *TUI) cancelHint() {
+	if self.hintSolver != nil {
+		self.hintSolver.Cancel()
+		<-self.hints
+		self.hintSolver = nil
+	}
+}
+
+// switchBranch cycles through the branches which can be redone from the current position
+func (self *TUI) switchBranch() {
+	branches := self.session.Branches()
+	if len(branches) < 2 {
+		self.message = "NO OTHER BRANCHES"
+		return
+	}
+	history, current := self.session.History()
+	next := history[current+1].command
+	for i, command := range branches {
+		if command == next {
+			self.session.SwitchBranch((i + 1) % len(branches))
+			break
+		}
+	}
+}
+
+func (self *TUI) save() {
+	path := self.sessionPath
+	if path == "" {
+		path = "session.json"
+	}
+	if err := self.session.Save(path); err != nil {
+		self.message = strings.ToUpper(err.Error())
+	} else {
+		self.message = fmt.Sprint("SAVED TO ", path)
+	}
+}
+
+func (self *TUI) redraw() {
+	board := self.boardLines()
+	sidebar := self.sidebarLines()
+	if len(sidebar) > len(board) {
+		// Keep the heading and only show the most recent commands
+		sidebar = append(sidebar[:1], sidebar[len(sidebar)-len(board)+1:]...)
+	}
+
+	// The sidebar is lined up against the widest line of the board
+	width := 0
+	for _, line := range board {
+		if w := len([]rune(StripColorCodes(line))); w > width {
+			width = w
+		}
+	}
+
+	var s strings.Builder
+	s.WriteString("\x1b[H\x1b[2J")
+	for i := 0; i < len(board) || i < len(sidebar); i++ {
+		line := ""
+		if i < len(board) {
+			line = board[i]
+		}
+		s.WriteString(line)
+		if i < len(sidebar) {
+			s.WriteString(strings.Repeat(" ", width-len([]rune(StripColorCodes(line)))))
+			s.WriteString("   ")
+			s.WriteString(sidebar[i])
+		}
+		s.WriteString("\r\n")
+	}
+	s.WriteString("\r\n")
+	s.WriteString(self.statusLine())
+	s.WriteString("\r\n")
+	s.WriteString("←↑→↓ cursor  ⏎ walk  r/l slide row  d/u slide column  t turn cell  z undo  y redo  b branch  h hint  w save  q quit")
+	fmt.Print(s.String())
+}
+
+// boardLines renders the maze marking the cursor and the cells the player can walk to
+func (self *TUI) boardLines() []string {
+	sequence := self.Sequence()
+	maze := sequence.maze
+
+	accessible := make([]bool, maze.TotalCells())
+	for location := range maze.AccessibleLocations(sequence.location) {
+		accessible[location] = true
+	}
+
+	options := self.renderOptions
+	options.Color = true
+	options.ShowExit = true
+	options.Highlighter = func(row int, column int) bool {
+		return row*int(maze.Columns())+column == int(self.cursor)
+	}
+	options.Reachable = func(row int, column int) bool {
+		return accessible[row*int(maze.Columns())+column]
+	}
+
+	var s strings.Builder
+	self.renderer.Render(&s, maze, sequence.location, options)
+	return strings.Split(strings.TrimSuffix(s.String(), "\n"), "\n")
+}
+
+// sidebarLines lists the commands played so far with any which can be redone shown faded
+func (self *TUI) sidebarLines() []string {
+	columns := self.Sequence().maze.Columns()
+	history, current := self.session.History()
+	lines := []string{colorize("yellow", "HISTORY")}
+	for i, sequence := range history[1:] {
+		line := fmt.Sprintf("%3d. %s", i+1, sequence.command.String(columns))
+		if i+1 == current {
+			line = colorize("green", line, " ◀")
+		} else if i+1 > current {
+			line = colorize("gray", line)
+		}
+		if i+1 == current+1 && len(self.session.Branches()) > 1 {
+			line += colorize("yellow", fmt.Sprintf(" (+%d branches)", len(self.session.Branches())-1))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (self *TUI) statusLine() string {
+	sequence := self.Sequence()
+	status := fmt.Sprintf("TURNS USED: %d   REMAINING: %d   CURSOR: %s", self.session.TurnsUsed(), sequence.turnsRemaining,
+		Command{MOVE, self.cursor, 0, 0, 0, 0}.String(sequence.maze.Columns()))
+	if sequence.IsFound() {
+		status += colorize("green", "   SOLVED!")
+	}
+	if self.message != "" {
+		status += colorize("red", "   ", self.message)
+	}
+	return status
+}
+
+// IsTerminal determines if the interactive TUI can be used (i.e. both input and output are a
+// terminal rather than a pipe or file)
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wallSides are the bits of a cell's nibble along with the bit of the neighbouring cell facing it
+var wallSides = []struct {
+	name     string
+	bit      byte
+	opposite byte
+}{
+	{"N", 8, 2},
+	{"E", 4, 1},
+	{"S", 2, 8},
+	{"W", 1, 4},
+}
+
+// WallMismatch is a side of a cell which is open while the neighbouring cell is closed on the
+// side facing it (so the player can't pass) or which opens onto the border of the maze
+type WallMismatch struct {
+	Location  uint8
+	Side      string // N, E, S or W
+	Neighbour int    // The cell on the other side of the wall (-1 for the border)
+}
+
+func (self WallMismatch) String(columns uint8) string {
+	cell := Command{MOVE, self.Location, 0, 0, 0, 0}.String(columns)
+	if self.Neighbour < 0 {
+		return fmt.Sprintf("%s %s opens onto the border", cell, self.Side)
+	}
+	neighbour := Command{MOVE, uint8(self.Neighbour), 0, 0, 0, 0}.String(columns)
+	opposite := map[string]string{"N": "S", "E": "W", "S": "N", "W": "E"}[self.Side]
+	return fmt.Sprintf("%s %s is open but %s %s is closed", cell, self.Side, neighbour, opposite)
+}
+
+// neighbourAcross is the cell on the other side of the given side of a cell or -1 for the border
+func (self *Maze) neighbourAcross(location uint8, bit byte) int {
+	columns := int(self.columns)
+	l := int(location)
+	switch bit {
+	case 8:
+		if l >= columns {
+			return l - columns
+		}
+	case 4:
+		if (l+1)%columns != 0 {
+			return l + 1
+		}
+	case 2:
+		if l+columns < int(self.TotalCells()) {
+			return l + columns
+		}
+	case 1:
+		if l%columns != 0 {
+			return l - 1
+		}
+	}
+	return -1
+}
+
+// MazeValidation lists the openings of a maze which the player can never pass through
+type MazeValidation struct {
+	Asymmetric []WallMismatch // Open on one side of a wall but not the other
+	Border     []WallMismatch // Open onto the outside of the maze
+}
+
+func (self *MazeValidation) Valid() bool {
+	return len(self.Asymmetric) == 0 && len(self.Border) == 0
+}
+
+// ValidateMaze finds every opening not matched by the neighbouring cell (walking only passes
+// through a wall when both sides agree, so these are usually typos in the pattern)
+func ValidateMaze(maze *Maze) *MazeValidation {
+	validation := &MazeValidation{Asymmetric: []WallMismatch{}, Border: []WallMismatch{}}
+	for location := uint8(0); location < maze.TotalCells(); location++ {
+		for _, side := range wallSides {
+			if maze.cells[location]&side.bit == 0 {
+				continue
+			}
+			neighbour := maze.neighbourAcross(location, side.bit)
+			mismatch := WallMismatch{location, side.name, neighbour}
+			if neighbour < 0 {
+				validation.Border = append(validation.Border, mismatch)
+			} else if maze.cells[neighbour]&side.opposite == 0 {
+				validation.Asymmetric = append(validation.Asymmetric, mismatch)
+			}
+		}
+	}
+	return validation
+}
+
+// Normalise closes every opening not matched by the neighbouring cell (including those onto the
+// border) so that the pattern only describes openings which can be walked through
+func (self *Maze) Normalise() *Maze {
+	maze := self.Copy()
+	validation := ValidateMaze(self)
+	for _, mismatch := range append(validation.Asymmetric, validation.Border...) {
+		for _, side := range wallSides {
+			if side.name == mismatch.Side {
+				maze.cells[mismatch.Location] &^= side.bit
+			}
+		}
+	}
+	return maze
+}
+
+// SlideMismatches are the asymmetric walls brought about by the slide: those between cells which
+// weren't neighbours (on those sides) before it.  Mismatches carried along from before the slide
+// aren't included.
+func (self *Maze) SlideMismatches(slide Command) ([]WallMismatch, error) {
+	slid, err := self.Slide(slide)
+	if err != nil {
+		return nil, err
+	}
+	origin := make([]uint8, self.TotalCells())
+	for location := uint8(0); location < self.TotalCells(); location++ {
+		origin[self.SlideLocation(slide, location)] = location
+	}
+
+	mismatches := []WallMismatch{}
+	for _, mismatch := range ValidateMaze(slid).Asymmetric {
+		for _, side := range wallSides {
+			if side.name == mismatch.Side && self.neighbourAcross(origin[mismatch.Location], side.bit) != int(origin[mismatch.Neighbour]) {
+				mismatches = append(mismatches, mismatch)
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+// SlideValidation is the mismatches brought about by a slide
+type SlideValidation struct {
+	Slide   Command
+	Before  *Maze
+	Induced []WallMismatch
+}
+
+func (self *SlideValidation) String() string {
+	columns := self.Before.Columns()
+	mismatches := []string{}
+	for _, mismatch := range self.Induced {
+		mismatches = append(mismatches, mismatch.String(columns))
+	}
+	return fmt.Sprintf("%s: %d mismatch(es) %s", self.Slide.String(columns), len(self.Induced), strings.Join(mismatches, ", "))
+}
+
+// ValidateSlides finds the mismatches brought about by each slide of the solution (moves are
+// skipped and the slides aren't checked against any rules).  Without a solution every slide
+// possible from the start is checked instead.
+func ValidateSlides(maze *Maze, solution string) ([]*SlideValidation, error) {
+	slides := []*SlideValidation{}
+	tokens := SolutionTokens(solution)
+	if len(tokens) == 0 {
+		all := []Command{}
+		for row := uint8(0); row < maze.Rows(); row++ {
+			all = append(all, Command{SLIDE_RIGHT, row, 0, 0, 0, 0}, Command{SLIDE_LEFT, row, 0, 0, 0, 0})
+		}
+		for column := uint8(0); column < maze.Columns(); column++ {
+			all = append(all, Command{SLIDE_DOWN, column, 0, 0, 0, 0}, Command{SLIDE_UP, column, 0, 0, 0, 0})
+		}
+		for _, slide := range all {
+			induced, err := maze.SlideMismatches(slide)
+			if err != nil {
+				return nil, err
+			}
+			slides = append(slides, &SlideValidation{slide, maze, induced})
+		}
+		return slides, nil
+	}
+
+	for i, token := range tokens {
+		cmd, err := ParseCommand(maze, token)
+		if err != nil {
+			return nil, fmt.Errorf("Step %d (%s): %s", i+1, token, err)
+		} else if cmd.operation != MOVE {
+			induced, err := maze.SlideMismatches(cmd)
+			if err != nil {
+				return nil, fmt.Errorf("Step %d (%s): %s", i+1, token, err)
+			}
+			slides = append(slides, &SlideValidation{cmd, maze, induced})
+			if maze, err = maze.Slide(cmd); err != nil {
+				return nil, fmt.Errorf("Step %d (%s): %s", i+1, token, err)
+			}
+		}
+	}
+	return slides, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// mismatchStrings describes the mismatches, separated by semicolons
+func mismatchStrings(mismatches []WallMismatch, columns uint8) string {
+	descriptions := []string{}
+	for _, mismatch := range mismatches {
+		descriptions = append(descriptions, mismatch.String(columns))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+func TestValidateMaze(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		columns    uint8
+		asymmetric string
+		border     string
+		normalised string
+	}{
+		{"653c59", 3, "", "", "653c59"},
+		{"643c59", 3, "(0,0) E is open but (0,1) W is closed", "", "243c59"},
+		{"e53c5b", 3, "", "(0,0) N opens onto the border; (1,2) S opens onto the border", "653c59"},
+	}
+	for _, test := range tests {
+		maze := mustParseMaze(test.pattern, test.columns)
+		validation := ValidateMaze(maze)
+		asymmetric := mismatchStrings(validation.Asymmetric, test.columns)
+		border := mismatchStrings(validation.Border, test.columns)
+		if asymmetric != test.asymmetric || border != test.border || validation.Valid() != (test.pattern == test.normalised) {
+			t.Errorf("%s: asymmetric %q and border %q", test.pattern, asymmetric, border)
+		}
+		if normalised := maze.Normalise().Pattern(); normalised != test.normalised || !ValidateMaze(maze.Normalise()).Valid() {
+			t.Errorf("%s: normalised as %s", test.pattern, normalised)
+		}
+	}
+}
+
+func TestValidateSlides(t *testing.T) {
+	maze := mustParseMaze("43020c596163c1c9", 4)
+	tests := []struct {
+		solution string
+		slides   string
+	}{
+		{"(1,3) U2 (3,3)", "U2: 4 mismatch(es) (0,2) E is open but (0,3) W is closed, (0,2) W is open but (0,1) E is closed, (1,1) E is open but (1,2) W is closed, (3,3) W is open but (3,2) E is closed"},
+		// Sliding back only restores the walls which matched before
+		{"R0 (0,0) L0", "R0: 4 mismatch(es) (0,0) S is open but (1,0) N is closed, (0,2) S is open but (1,2) N is closed, (1,1) N is open but (0,1) S is closed, (1,3) N is open but (0,3) S is closed; L0: 0 mismatch(es) "},
+	}
+	for _, test := range tests {
+		validations, err := ValidateSlides(maze, test.solution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slides := []string{}
+		for _, validation := range validations {
+			slides = append(slides, validation.String())
+		}
+		if got := strings.Join(slides, "; "); got != test.slides {
+			t.Errorf("%s: %s", test.solution, got)
+		}
+	}
+
+	if all, err := ValidateSlides(maze, ""); err != nil || len(all) != 16 {
+		t.Errorf("Validated %d slide(s) from the start (%v)", len(all), err)
+	}
+	if _, err := ValidateSlides(maze, "U4"); err == nil {
+		t.Error("Validated a slide off the maze")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verification is the result of replaying a solution against a maze
+type Verification struct {
+	Sequence    *Sequence // The sequence arrived at (up to the failing step if any)
+	TurnsUsed   int
+	StepsWalked int
+	Step        int    // The (1-based) step which failed or 0 if the solution is valid
+	Token       string // The command text of the failing step
+	Reason      string
+}
+
+func (self *Verification) Valid() bool {
+	return self.Step == 0
+}
+
+func (self *Verification) String() string {
+	if self.Valid() {
+		return fmt.Sprintf("VALID: reaches the exit in %d turn(s) walking %d step(s)", self.TurnsUsed, self.StepsWalked)
+	} else if self.Token == "" {
+		return fmt.Sprintf("INVALID: %s", self.Reason)
+	}
+	return fmt.Sprintf("INVALID: step %d (%s): %s", self.Step, self.Token, self.Reason)
+}
+
+// SolutionTokens splits a solution as written in the READMEs (e.g. "(0,0) C3 (0,0) R5 (6,6)")
+// into individual commands.  A leading "SOLUTION:" label is ignored.
+func SolutionTokens(solution string) []string {
+	tokens := strings.Fields(solution)
+	if len(tokens) > 0 && strings.EqualFold(tokens[0], "SOLUTION:") {
+		tokens = tokens[1:]
+	}
+	return tokens
+}
+
+// Verify replays the solution from the start of the maze, checking that every command is legal
+// under the rules, that the turns used stay within budget and that the exit is reached (by the
+// last command, with none following it).
+func Verify(maze *Maze, turns uint8, rules *Rules, solution string) *Verification {
+	sequence := NewSequence(maze, turns, rules)
+	v := &Verification{Sequence: sequence}
+	if err := rules.CheckMaze(maze); err != nil {
+		v.Step = -1
+		v.Reason = err.Error()
+		return v
+	}
+
+	for i, token := range SolutionTokens(solution) {
+		fail := func(format string, a ...interface{}) *Verification {
+			v.Step = i + 1
+			v.Token = token
+			v.Reason = fmt.Sprintf(format, a...)
+			return v
+		}
+
+		if sequence.IsFound() {
+			return fail("the exit has already been reached")
+		}
+		cmd, err := sequence.CommandFromString(token)
+		if err != nil {
+			return fail("%s", err)
+		}
+		if !sequence.CanApply(cmd) {
+			return fail("not allowed under %s rules from %s", rules, Command{MOVE, sequence.location, 0, 0, 0, 0}.String(maze.Columns()))
+		}
+		cost := sequence.TurnCost(cmd)
+		if int(cost) > int(sequence.turnsRemaining) {
+			return fail("exceeds the budget of %d turn(s)", turns)
+		}
+		next, err := sequence.Apply(cmd)
+		if err != nil {
+			return fail("%s", err)
+		}
+		sequence = next
+		v.Sequence = sequence
+		v.TurnsUsed += int(cost)
+		if path := sequence.Path(); path != nil {
+			v.StepsWalked += len(path) - 1
+		}
+	}
+
+	if !sequence.IsFound() {
+		v.Step = -1
+		v.Reason = fmt.Sprintf("does not reach the exit (ends at %s)", Command{MOVE, sequence.location, 0, 0, 0, 0}.String(maze.Columns()))
+	}
+	return v
+}
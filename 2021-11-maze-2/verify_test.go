@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	maze := mustParseMaze("43020c596163c1c9", 4)
+	tests := []struct {
+		turns    uint8
+		solution string
+		want     string
+	}{
+		{3, "(1,3) U2 (3,3)", "VALID: reaches the exit in 3 turn(s) walking 8 step(s)"},
+		{3, "SOLUTION: (1,3) U2 (3,3)", "VALID: reaches the exit in 3 turn(s) walking 8 step(s)"},
+		{4, "(1,3) U2 (3,3) (2,3)", "INVALID: step 4 ((2,3)): the exit has already been reached"},
+		{2, "(1,3) U2 (3,3)", "INVALID: step 3 ((3,3)): exceeds the budget of 2 turn(s)"},
+		{3, "(3,3)", "INVALID: step 1 ((3,3)): not allowed under november rules from (0,0)"},
+		{3, "(1,3) X2", "INVALID: step 2 (X2): Can not parse command: X2"},
+		{3, "(1,3) U2", "INVALID: does not reach the exit (ends at (1,3))"},
+	}
+	for _, test := range tests {
+		if got := Verify(maze, test.turns, NovemberRules, test.solution).String(); got != test.want {
+			t.Errorf("%s in %d: %s, expected %s", test.solution, test.turns, got, test.want)
+		}
+	}
+}
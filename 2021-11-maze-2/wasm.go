@@ -0,0 +1,37 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// main offers the API to JavaScript as a global mazeIBM object
+func main() {
+	js.Global().Set("mazeIBM", exportAPI())
+	select {}
+}
+
+// exportAPI is an object with a function for each API call (maze, apply, commands and solve).
+// Each takes a request as JSON and returns a Promise of the response as JSON.
+func exportAPI() js.Value {
+	api := js.Global().Get("Object").New()
+	for name := range apiCalls {
+		name := name
+		api.Set(name, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			request := ""
+			if len(args) > 0 {
+				request = args[0].String()
+			}
+			// Calls run in their own goroutine since a solve can't finish while JavaScript is blocked
+			return js.Global().Get("Promise").New(js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+				resolve := args[0]
+				go func() {
+					resolve.Invoke(string(callAPI(name, []byte(request))))
+				}()
+				return nil
+			}))
+		}))
+	}
+	return api
+}
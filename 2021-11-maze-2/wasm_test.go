@@ -0,0 +1,54 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+	"testing"
+)
+
+// await waits for the promise to resolve, returning what it resolved to
+func await(promise js.Value) js.Value {
+	resolved := make(chan js.Value, 1)
+	then := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		resolved <- args[0]
+		return nil
+	})
+	defer then.Release()
+	promise.Call("then", then)
+	return <-resolved
+}
+
+// TestExportedAPI calls the functions offered to JavaScript just as a page would
+func TestExportedAPI(t *testing.T) {
+	api := exportAPI()
+	for name := range apiCalls {
+		if api.Get(name).Type() != js.TypeFunction {
+			t.Errorf("%s: not exported", name)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		request string
+		field   string
+		want    interface{}
+	}{
+		{"maze", `{` + apiTestScenario + `}`, "TurnsRemaining", 3.0},
+		{"apply", `{` + apiTestScenario + `, "Commands": ["(1,3)", "U2"], "Command": "(3,3)"}`, "Solved", true},
+		{"solve", `{` + apiTestScenario + `, "Timeout": "10s"}`, "Status", "solved"},
+		{"maze", `{"MazePattern": "43020c", "Columns": 4, "Rows": 4, "Turns": 3}`, "Error", "Maze pattern is not of size 4x4"},
+	}
+	for _, test := range tests {
+		result := await(api.Call(test.name, test.request))
+		if result.Type() != js.TypeString {
+			t.Errorf("%s %s: resolved to a %s", test.name, test.request, result.Type())
+			continue
+		}
+		response := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(result.String()), &response); err != nil || response[test.field] != test.want {
+			t.Errorf("%s %s: unexpected response %s", test.name, test.request, result.String())
+		}
+	}
+}